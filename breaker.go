@@ -0,0 +1,323 @@
+package railguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and tracks their failure rate.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen fails requests fast with ErrCircuitOpen.
+	CircuitOpen
+
+	// CircuitHalfOpen lets a limited number of trial requests through to
+	// test whether the provider has recovered.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Window is the rolling window over which the failure rate is measured.
+	Window time.Duration
+
+	// MinRequests is the minimum number of requests in the window before the
+	// breaker may open, so that a single early failure does not trip it.
+	MinRequests int
+
+	// FailureRate is the fraction of failed requests in the window, between
+	// 0 and 1, at which the breaker opens.
+	FailureRate float64
+
+	// OpenDuration is how long the breaker stays open before half-opening.
+	OpenDuration time.Duration
+
+	// HalfOpenRequests is the number of trial requests let through while
+	// half-open. The breaker closes once all of them succeed and opens again
+	// as soon as one fails.
+	HalfOpenRequests int
+
+	// OnStateChange is called after every state transition. It is optional.
+	OnStateChange func(from, to CircuitState)
+
+	// Now returns the current time. It defaults to time.Now and can be
+	// replaced in tests.
+	Now func() time.Time
+}
+
+// DefaultCircuitBreakerConfig returns a sensible default circuit breaker configuration.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Window:           30 * time.Second,
+		MinRequests:      10,
+		FailureRate:      0.5,
+		OpenDuration:     30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// Validate checks that the circuit breaker configuration is valid.
+func (c CircuitBreakerConfig) Validate() error {
+	if c.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+	if c.MinRequests < 1 {
+		return errors.New("min requests must be at least 1")
+	}
+	if c.FailureRate <= 0 || c.FailureRate > 1 {
+		return errors.New("failure rate must be between 0 and 1")
+	}
+	if c.OpenDuration <= 0 {
+		return errors.New("open duration must be positive")
+	}
+	if c.HalfOpenRequests < 1 {
+		return errors.New("half-open requests must be at least 1")
+	}
+	return nil
+}
+
+// CircuitBreaker is a Client that stops calling a failing provider.
+// While closed, it tracks the rate of failed requests in a rolling window
+// and opens once the rate reaches the configured threshold. While open,
+// requests fail immediately with ErrCircuitOpen, which Guard.Run does not
+// retry. After OpenDuration the breaker half-opens and lets trial requests
+// through to decide whether to close again.
+//
+// Requests that fail because their context was canceled do not count as
+// failures, but requests that fail because their context deadline expired,
+// e.g. with WithAttemptTimeout, do. Errors that implement TemporaryError and
+// report false, such as authentication failures or invalid requests, are
+// classified as by DefaultRetryPolicy: the provider answered, so they count as
+// successes. CircuitBreaker also implements ChatClient, so wrapping a chat
+// client keeps its native message support. It is safe for concurrent use.
+type CircuitBreaker struct {
+	client Client
+	chat   ChatClient
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    CircuitState
+	openedAt time.Time
+	// generation is incremented on every state change so that requests
+	// started in an earlier state do not affect the current one.
+	generation     uint64
+	outcomes       []outcome
+	trials         int
+	trialSuccesses int
+}
+
+// outcome is the result of a request, recorded for the rolling window.
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// stateChange is a transition waiting to be reported to OnStateChange.
+type stateChange struct {
+	from, to CircuitState
+}
+
+// NewCircuitBreaker wraps client with a circuit breaker.
+// Returns ErrNilClient if client is nil, or ErrInvalidCircuitBreakerConfig
+// if the configuration is invalid.
+func NewCircuitBreaker(client Client, config CircuitBreakerConfig) (*CircuitBreaker, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCircuitBreakerConfig, err)
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &CircuitBreaker{
+		client: client,
+		chat:   AsChatClient(client),
+		config: config,
+	}, nil
+}
+
+// Generate implements the Client interface.
+func (b *CircuitBreaker) Generate(ctx context.Context, prompt string) (string, error) {
+	return b.call(ctx, func(ctx context.Context) (string, error) {
+		return b.client.Generate(ctx, prompt)
+	})
+}
+
+// Chat implements the ChatClient interface.
+func (b *CircuitBreaker) Chat(ctx context.Context, messages []Message) (string, error) {
+	return b.call(ctx, func(ctx context.Context) (string, error) {
+		return b.chat.Chat(ctx, messages)
+	})
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	changes := b.advance()
+	state := b.state
+	b.mu.Unlock()
+
+	b.notify(changes)
+	return state
+}
+
+// call runs fn if the breaker allows it and records the outcome.
+func (b *CircuitBreaker) call(ctx context.Context, fn func(context.Context) (string, error)) (string, error) {
+	generation, err := b.acquire()
+	if err != nil {
+		return "", err
+	}
+
+	// A deadline, such as an attempt timeout, still counts: a provider that
+	// hangs until the timeout is the outage the breaker is for. A permanent
+	// error, such as a rejected request, is an answer from a healthy provider
+	output, err := fn(ctx)
+	failed := err != nil && !permanent(err)
+	b.record(generation, failed, err != nil && errors.Is(ctx.Err(), context.Canceled))
+	return output, err
+}
+
+// acquire checks whether a request may proceed and returns the generation it
+// belongs to. Returns ErrCircuitOpen if the request is rejected.
+func (b *CircuitBreaker) acquire() (uint64, error) {
+	b.mu.Lock()
+	changes := b.advance()
+	generation := b.generation
+	var err error
+	switch b.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.trials >= b.config.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			b.trials++
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+	return generation, err
+}
+
+// record updates the breaker with the outcome of a request.
+// Canceled requests say nothing about the provider and are not counted.
+func (b *CircuitBreaker) record(generation uint64, failed, canceled bool) {
+	b.mu.Lock()
+	var changes []stateChange
+	if generation == b.generation {
+		changes = b.recordLocked(failed, canceled)
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+}
+
+// recordLocked updates the breaker state. b.mu must be held.
+func (b *CircuitBreaker) recordLocked(failed, canceled bool) []stateChange {
+	now := b.config.Now()
+
+	switch b.state {
+	case CircuitClosed:
+		if canceled {
+			return nil
+		}
+		b.outcomes = append(b.outcomes, outcome{at: now, failed: failed})
+		b.prune(now)
+
+		failures := 0
+		for _, o := range b.outcomes {
+			if o.failed {
+				failures++
+			}
+		}
+		if len(b.outcomes) >= b.config.MinRequests &&
+			float64(failures)/float64(len(b.outcomes)) >= b.config.FailureRate {
+			return b.setState(CircuitOpen, now)
+		}
+
+	case CircuitHalfOpen:
+		switch {
+		case canceled:
+			// Free the trial slot for another request
+			b.trials--
+		case failed:
+			return b.setState(CircuitOpen, now)
+		default:
+			b.trialSuccesses++
+			if b.trialSuccesses >= b.config.HalfOpenRequests {
+				return b.setState(CircuitClosed, now)
+			}
+		}
+	}
+	return nil
+}
+
+// advance half-opens the breaker once the open duration has elapsed.
+// b.mu must be held.
+func (b *CircuitBreaker) advance() []stateChange {
+	now := b.config.Now()
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.config.OpenDuration {
+		return b.setState(CircuitHalfOpen, now)
+	}
+	return nil
+}
+
+// prune drops outcomes that fell out of the rolling window. b.mu must be held.
+func (b *CircuitBreaker) prune(now time.Time) {
+	cutoff := now.Add(-b.config.Window)
+	i := 0
+	for i < len(b.outcomes) && !b.outcomes[i].at.After(cutoff) {
+		i++
+	}
+	b.outcomes = b.outcomes[i:]
+}
+
+// setState transitions the breaker and resets the per-state counters.
+// b.mu must be held.
+func (b *CircuitBreaker) setState(to CircuitState, now time.Time) []stateChange {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.outcomes = nil
+	b.trials = 0
+	b.trialSuccesses = 0
+	if to == CircuitOpen {
+		b.openedAt = now
+	}
+	return []stateChange{{from: from, to: to}}
+}
+
+// notify reports state transitions to OnStateChange outside the lock,
+// so that the callback may inspect the breaker.
+func (b *CircuitBreaker) notify(changes []stateChange) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, c := range changes {
+		b.config.OnStateChange(c.from, c.to)
+	}
+}
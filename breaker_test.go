@@ -0,0 +1,414 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// fakeClock is a manually advanced clock for circuit breaker tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// switchClient fails while failing is set.
+type switchClient struct {
+	mu      sync.Mutex
+	failing bool
+	calls   int
+}
+
+func (c *switchClient) Generate(ctx context.Context, prompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.failing {
+		return "", errors.New("provider down")
+	}
+	return "ok", nil
+}
+
+func (c *switchClient) setFailing(failing bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failing = failing
+}
+
+func testBreakerConfig(clock *fakeClock, changes *[]string) railguard.CircuitBreakerConfig {
+	return railguard.CircuitBreakerConfig{
+		Window:           10 * time.Second,
+		MinRequests:      4,
+		FailureRate:      0.5,
+		OpenDuration:     5 * time.Second,
+		HalfOpenRequests: 2,
+		Now:              clock.Now,
+		OnStateChange: func(from, to railguard.CircuitState) {
+			*changes = append(*changes, from.String()+"->"+to.String())
+		},
+	}
+}
+
+func TestNewCircuitBreaker(t *testing.T) {
+	t.Run("nil client", func(t *testing.T) {
+		_, err := railguard.NewCircuitBreaker(nil, railguard.DefaultCircuitBreakerConfig())
+		if !errors.Is(err, railguard.ErrNilClient) {
+			t.Errorf("expected ErrNilClient, got %v", err)
+		}
+	})
+
+	t.Run("default config", func(t *testing.T) {
+		b, err := railguard.NewCircuitBreaker(&mockClient{}, railguard.DefaultCircuitBreakerConfig())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b.State() != railguard.CircuitClosed {
+			t.Errorf("expected closed breaker, got %v", b.State())
+		}
+	})
+
+	invalid := []struct {
+		name   string
+		modify func(*railguard.CircuitBreakerConfig)
+	}{
+		{"zero window", func(c *railguard.CircuitBreakerConfig) { c.Window = 0 }},
+		{"zero min requests", func(c *railguard.CircuitBreakerConfig) { c.MinRequests = 0 }},
+		{"zero failure rate", func(c *railguard.CircuitBreakerConfig) { c.FailureRate = 0 }},
+		{"failure rate above 1", func(c *railguard.CircuitBreakerConfig) { c.FailureRate = 1.5 }},
+		{"zero open duration", func(c *railguard.CircuitBreakerConfig) { c.OpenDuration = 0 }},
+		{"zero half-open requests", func(c *railguard.CircuitBreakerConfig) { c.HalfOpenRequests = 0 }},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			config := railguard.DefaultCircuitBreakerConfig()
+			tt.modify(&config)
+			_, err := railguard.NewCircuitBreaker(&mockClient{}, config)
+			if !errors.Is(err, railguard.ErrInvalidCircuitBreakerConfig) {
+				t.Errorf("expected ErrInvalidCircuitBreakerConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("opens at failure rate and fails fast", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := &switchClient{}
+		b, err := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+		if err != nil {
+			t.Fatalf("failed to create breaker: %v", err)
+		}
+
+		// Below MinRequests the breaker stays closed
+		client.setFailing(true)
+		for i := 0; i < 3; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		if b.State() != railguard.CircuitClosed {
+			t.Fatalf("expected closed breaker below min requests, got %v", b.State())
+		}
+
+		_, _ = b.Generate(ctx, "test")
+		if b.State() != railguard.CircuitOpen {
+			t.Fatalf("expected open breaker, got %v", b.State())
+		}
+
+		calls := client.calls
+		if _, err := b.Generate(ctx, "test"); !errors.Is(err, railguard.ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+		if client.calls != calls {
+			t.Error("expected open breaker not to call the client")
+		}
+		if len(changes) != 1 || changes[0] != "closed->open" {
+			t.Errorf("unexpected state changes: %v", changes)
+		}
+	})
+
+	t.Run("failures outside the window are forgotten", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := &switchClient{failing: true}
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 3; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		clock.Advance(11 * time.Second)
+		client.setFailing(false)
+		_, _ = b.Generate(ctx, "test")
+		client.setFailing(true)
+		_, _ = b.Generate(ctx, "test")
+
+		if b.State() != railguard.CircuitClosed {
+			t.Errorf("expected closed breaker, got %v", b.State())
+		}
+	})
+
+	t.Run("half-opens and closes after successful trials", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := &switchClient{failing: true}
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 4; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		clock.Advance(5 * time.Second)
+		if b.State() != railguard.CircuitHalfOpen {
+			t.Fatalf("expected half-open breaker, got %v", b.State())
+		}
+
+		client.setFailing(false)
+		for i := 0; i < 2; i++ {
+			if _, err := b.Generate(ctx, "test"); err != nil {
+				t.Fatalf("trial %d: unexpected error: %v", i+1, err)
+			}
+		}
+		if b.State() != railguard.CircuitClosed {
+			t.Errorf("expected closed breaker, got %v", b.State())
+		}
+
+		want := []string{"closed->open", "open->half-open", "half-open->closed"}
+		if len(changes) != len(want) {
+			t.Fatalf("expected state changes %v, got %v", want, changes)
+		}
+		for i := range want {
+			if changes[i] != want[i] {
+				t.Errorf("expected state changes %v, got %v", want, changes)
+				break
+			}
+		}
+	})
+
+	t.Run("failed trial reopens", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := &switchClient{failing: true}
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 4; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		clock.Advance(5 * time.Second)
+		_, _ = b.Generate(ctx, "test")
+
+		if b.State() != railguard.CircuitOpen {
+			t.Errorf("expected reopened breaker, got %v", b.State())
+		}
+		if changes[len(changes)-1] != "half-open->open" {
+			t.Errorf("unexpected state changes: %v", changes)
+		}
+	})
+
+	t.Run("limits concurrent trials", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		release := make(chan struct{})
+		started := make(chan struct{}, 2)
+		failing := true
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			if failing {
+				return "", errors.New("provider down")
+			}
+			started <- struct{}{}
+			<-release
+			return "ok", nil
+		})
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 4; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		clock.Advance(5 * time.Second)
+		failing = false
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = b.Generate(ctx, "test")
+			}()
+		}
+		<-started
+		<-started
+
+		if _, err := b.Generate(ctx, "test"); !errors.Is(err, railguard.ErrCircuitOpen) {
+			t.Errorf("expected third trial to be rejected, got %v", err)
+		}
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("canceled requests are not failures", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "", ctx.Err()
+		})
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		for i := 0; i < 10; i++ {
+			_, _ = b.Generate(canceled, "test")
+		}
+		if b.State() != railguard.CircuitClosed {
+			t.Errorf("expected closed breaker, got %v", b.State())
+		}
+	})
+
+	t.Run("permanent errors are not failures", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "", &statusError{code: 401}
+		})
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 10; i++ {
+			if _, err := b.Generate(ctx, "test"); errors.Is(err, railguard.ErrCircuitOpen) {
+				t.Fatalf("request %d rejected by open breaker", i)
+			}
+		}
+		if b.State() != railguard.CircuitClosed {
+			t.Errorf("expected closed breaker, got %v", b.State())
+		}
+	})
+
+	t.Run("temporary errors are failures", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "", &statusError{code: 503}
+		})
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+
+		for i := 0; i < 4; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+		if b.State() != railguard.CircuitOpen {
+			t.Errorf("expected open breaker, got %v", b.State())
+		}
+	})
+
+	t.Run("timed out requests are failures", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		hanging := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+		b, _ := railguard.NewCircuitBreaker(hanging, testBreakerConfig(clock, &changes))
+
+		g, err := railguard.New(
+			railguard.WithClient(b),
+			railguard.WithAttemptTimeout(5*time.Millisecond),
+			railguard.WithRetry(railguard.RetryConfig{
+				MaxAttempts:  4,
+				InitialDelay: time.Millisecond,
+				MaxDelay:     time.Millisecond,
+				Multiplier:   1,
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Run(ctx, "test")
+		if !errors.Is(err, railguard.ErrAttemptTimeout) {
+			t.Errorf("expected ErrAttemptTimeout, got %v", err)
+		}
+		if b.State() != railguard.CircuitOpen {
+			t.Errorf("expected open breaker after timeouts, got %v", b.State())
+		}
+	})
+
+	t.Run("open breaker is not retried by Guard", func(t *testing.T) {
+		clock := newFakeClock()
+		var changes []string
+		client := &switchClient{failing: true}
+		b, _ := railguard.NewCircuitBreaker(client, testBreakerConfig(clock, &changes))
+		for i := 0; i < 4; i++ {
+			_, _ = b.Generate(ctx, "test")
+		}
+
+		g, err := railguard.New(railguard.WithClient(b), railguard.WithMaxRetries(3))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(ctx, "test")
+		if result != nil {
+			t.Error("expected nil result")
+		}
+		var genErr *railguard.GenerationError
+		if !errors.As(err, &genErr) || !errors.Is(err, railguard.ErrCircuitOpen) {
+			t.Fatalf("expected GenerationError wrapping ErrCircuitOpen, got %v", err)
+		}
+		var maxErr *railguard.MaxRetriesError
+		if errors.As(err, &maxErr) {
+			t.Error("expected open breaker not to be retried")
+		}
+	})
+
+	t.Run("keeps chat support", func(t *testing.T) {
+		var got []railguard.Message
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			got = messages
+			return "ok", nil
+		})
+		b, err := railguard.NewCircuitBreaker(railguard.AsClient(chat), railguard.DefaultCircuitBreakerConfig())
+		if err != nil {
+			t.Fatalf("failed to create breaker: %v", err)
+		}
+
+		messages := []railguard.Message{
+			{Role: railguard.RoleSystem, Content: "be brief"},
+			{Role: railguard.RoleUser, Content: "hi"},
+		}
+		if _, err := b.Chat(ctx, messages); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 {
+			t.Errorf("expected messages to be passed through, got %v", got)
+		}
+	})
+}
+
+func TestCircuitStateString(t *testing.T) {
+	tests := map[railguard.CircuitState]string{
+		railguard.CircuitClosed:   "closed",
+		railguard.CircuitOpen:     "open",
+		railguard.CircuitHalfOpen: "half-open",
+	}
+	for state, want := range tests {
+		if got := state.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package railguard
+
+import (
+	"sync"
+)
+
+// RetryBudget limits retries across all runs of the Guards it is attached to,
+// similar to gRPC retry throttling. It is a token bucket: every retry spends
+// one token, every successful attempt refills a fraction of a token, and no
+// retries are made while fewer than one token is left. During a provider
+// outage this stops concurrent runs from multiplying the load on the provider.
+//
+// A RetryBudget is safe for concurrent use and can be shared by several
+// Guards with WithRetryBudget.
+type RetryBudget struct {
+	mu        sync.Mutex
+	maxTokens float64
+	refill    float64
+	tokens    float64
+	successes int64
+	retries   int64
+	throttled int64
+}
+
+// RetryBudgetStats is a snapshot of the state of a RetryBudget.
+type RetryBudgetStats struct {
+	// Tokens is the number of tokens currently in the bucket.
+	Tokens float64
+
+	// MaxTokens is the capacity of the bucket.
+	MaxTokens float64
+
+	// Successes is the number of successful attempts that refilled the bucket.
+	Successes int64
+
+	// Retries is the number of retries the budget allowed.
+	Retries int64
+
+	// Throttled is the number of retries the budget refused.
+	Throttled int64
+}
+
+// NewRetryBudget creates a full RetryBudget holding maxTokens tokens, where
+// each successful attempt refills refill tokens. For example, a budget of 10
+// tokens with a refill of 0.1 allows bursts of 10 retries and sustains one
+// retry per 10 successes. Returns ErrInvalidRetryBudget if maxTokens is less
+// than 1 or refill is negative.
+func NewRetryBudget(maxTokens, refill float64) (*RetryBudget, error) {
+	if maxTokens < 1 || refill < 0 {
+		return nil, ErrInvalidRetryBudget
+	}
+	return &RetryBudget{
+		maxTokens: maxTokens,
+		refill:    refill,
+		tokens:    maxTokens,
+	}, nil
+}
+
+// Stats returns a snapshot of the budget's current state.
+func (b *RetryBudget) Stats() RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return RetryBudgetStats{
+		Tokens:    b.tokens,
+		MaxTokens: b.maxTokens,
+		Successes: b.successes,
+		Retries:   b.retries,
+		Throttled: b.throttled,
+	}
+}
+
+// allowRetry spends a token for a retry. Returns false if the bucket is empty.
+func (b *RetryBudget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		b.throttled++
+		return false
+	}
+	b.tokens--
+	b.retries++
+	return true
+}
+
+// recordSuccess refills the bucket after a successful attempt.
+func (b *RetryBudget) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.successes++
+	b.tokens = min(b.tokens+b.refill, b.maxTokens)
+}
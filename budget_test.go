@@ -0,0 +1,160 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+func TestNewRetryBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTokens float64
+		refill    float64
+		wantErr   bool
+	}{
+		{name: "valid", maxTokens: 10, refill: 0.1},
+		{name: "no refill", maxTokens: 1, refill: 0},
+		{name: "less than one token", maxTokens: 0.5, refill: 0.1, wantErr: true},
+		{name: "negative refill", maxTokens: 10, refill: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget, err := railguard.NewRetryBudget(tt.maxTokens, tt.refill)
+			if tt.wantErr {
+				if !errors.Is(err, railguard.ErrInvalidRetryBudget) {
+					t.Errorf("expected ErrInvalidRetryBudget, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stats := budget.Stats()
+			if stats.Tokens != tt.maxTokens || stats.MaxTokens != tt.maxTokens {
+				t.Errorf("expected full bucket of %v tokens, got %+v", tt.maxTokens, stats)
+			}
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	failing := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		return "", errors.New("provider unavailable")
+	})
+
+	t.Run("throttles retries once empty", func(t *testing.T) {
+		budget, err := railguard.NewRetryBudget(2, 0.5)
+		if err != nil {
+			t.Fatalf("failed to create budget: %v", err)
+		}
+
+		g, err := railguard.New(
+			railguard.WithClient(failing),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 5, Multiplier: 1}),
+			railguard.WithRetryBudget(budget),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Run(context.Background(), "test")
+		var abortErr *railguard.RetryAbortedError
+		if !errors.As(err, &abortErr) {
+			t.Fatalf("expected RetryAbortedError, got %v", err)
+		}
+		if !errors.Is(err, railguard.ErrRetryBudgetExhausted) {
+			t.Errorf("expected ErrRetryBudgetExhausted, got %v", err)
+		}
+		if abortErr.Attempts != 3 {
+			t.Errorf("expected 3 attempts (2 retries), got %d", abortErr.Attempts)
+		}
+
+		stats := budget.Stats()
+		if stats.Tokens != 0 || stats.Retries != 2 || stats.Throttled != 1 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("shared across guards and refilled by successes", func(t *testing.T) {
+		budget, err := railguard.NewRetryBudget(1, 0.5)
+		if err != nil {
+			t.Fatalf("failed to create budget: %v", err)
+		}
+
+		failingGuard, err := railguard.New(
+			railguard.WithClient(failing),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 3, Multiplier: 1}),
+			railguard.WithRetryBudget(budget),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+		healthyGuard, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithRetryBudget(budget),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		// Spend the only token
+		_, _ = failingGuard.Run(context.Background(), "test")
+		if tokens := budget.Stats().Tokens; tokens != 0 {
+			t.Fatalf("expected empty bucket, got %v tokens", tokens)
+		}
+
+		// Two successes refill one token
+		for i := 0; i < 2; i++ {
+			if _, err := healthyGuard.Run(context.Background(), "test"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		stats := budget.Stats()
+		if stats.Tokens != 1 || stats.Successes != 2 {
+			t.Errorf("expected 1 token after 2 successes, got %+v", stats)
+		}
+
+		// Refill is capped at the bucket capacity
+		for i := 0; i < 3; i++ {
+			_, _ = healthyGuard.Run(context.Background(), "test")
+		}
+		if tokens := budget.Stats().Tokens; tokens != 1 {
+			t.Errorf("expected bucket capped at 1 token, got %v", tokens)
+		}
+	})
+
+	t.Run("concurrent runs", func(t *testing.T) {
+		budget, err := railguard.NewRetryBudget(5, 0)
+		if err != nil {
+			t.Fatalf("failed to create budget: %v", err)
+		}
+
+		g, err := railguard.New(
+			railguard.WithClient(failing),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 3, Multiplier: 1}),
+			railguard.WithRetryBudget(budget),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = g.Run(context.Background(), "test")
+			}()
+		}
+		wg.Wait()
+
+		if stats := budget.Stats(); stats.Retries != 5 || stats.Tokens != 0 {
+			t.Errorf("expected exactly 5 retries across runs, got %+v", stats)
+		}
+	})
+}
@@ -22,3 +22,71 @@ func (f ClientFunc) Generate(ctx context.Context, prompt string) (string, error)
 	return f(ctx, prompt)
 }
 
+
+// ChatClient represents any LLM that can generate text from a list of chat messages.
+// Unlike Client, it keeps system instructions, user turns, and assistant turns
+// separate, so providers with native chat APIs can map them to their own roles.
+type ChatClient interface {
+	// Chat produces a response for the given conversation.
+	// The context should be used for cancellation and timeouts.
+	// Returns the generated text or an error if generation fails.
+	Chat(ctx context.Context, messages []Message) (string, error)
+}
+
+// ChatClientFunc is an adapter that allows ordinary functions to be used as ChatClients.
+type ChatClientFunc func(ctx context.Context, messages []Message) (string, error)
+
+// Chat implements the ChatClient interface by calling the function itself.
+func (f ChatClientFunc) Chat(ctx context.Context, messages []Message) (string, error) {
+	return f(ctx, messages)
+}
+
+// AsChatClient adapts a Client to the ChatClient interface.
+// If the client already implements ChatClient, it is returned as is, and a
+// client returned by AsClient is unwrapped to the original ChatClient.
+// Otherwise the messages are flattened into a single prompt: a conversation
+// consisting of one user message is sent as that message's content, and
+// longer conversations are sent as role-labeled paragraphs.
+func AsChatClient(client Client) ChatClient {
+	switch c := client.(type) {
+	case ChatClient:
+		return c
+	case *promptClient:
+		return c.chat
+	}
+	return &flatChatClient{client: client}
+}
+
+// flatChatClient implements ChatClient on top of a prompt-based Client.
+type flatChatClient struct {
+	client Client
+}
+
+// Chat flattens the messages into a prompt and calls the wrapped client.
+func (c *flatChatClient) Chat(ctx context.Context, messages []Message) (string, error) {
+	return c.client.Generate(ctx, flattenMessages(messages))
+}
+
+// AsClient adapts a ChatClient to the Client interface.
+// If the chat client already implements Client, it is returned as is, and a
+// chat client returned by AsChatClient is unwrapped to the original Client.
+// Otherwise each prompt is sent as a single user message.
+func AsClient(chat ChatClient) Client {
+	switch c := chat.(type) {
+	case Client:
+		return c
+	case *flatChatClient:
+		return c.client
+	}
+	return &promptClient{chat: chat}
+}
+
+// promptClient implements Client on top of a ChatClient.
+type promptClient struct {
+	chat ChatClient
+}
+
+// Generate sends the prompt as a single user message.
+func (c *promptClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.chat.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
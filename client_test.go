@@ -55,3 +55,99 @@ func TestClientFunc(t *testing.T) {
 	})
 }
 
+
+func TestAsChatClient(t *testing.T) {
+	t.Run("single user message is sent verbatim", func(t *testing.T) {
+		var got string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			got = prompt
+			return "ok", nil
+		})
+
+		chat := railguard.AsChatClient(client)
+		if _, err := chat.Chat(context.Background(), []railguard.Message{
+			{Role: railguard.RoleUser, Content: "hello"},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "hello" {
+			t.Errorf("expected 'hello', got %q", got)
+		}
+	})
+
+	t.Run("conversation is flattened with role labels", func(t *testing.T) {
+		var got string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			got = prompt
+			return "ok", nil
+		})
+
+		chat := railguard.AsChatClient(client)
+		if _, err := chat.Chat(context.Background(), []railguard.Message{
+			{Role: railguard.RoleSystem, Content: "be brief"},
+			{Role: railguard.RoleUser, Content: "hello"},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "System: be brief\n\nUser: hello" {
+			t.Errorf("unexpected flattened prompt: %q", got)
+		}
+	})
+
+	t.Run("returns native chat clients as is", func(t *testing.T) {
+		native := &dualClient{}
+		if railguard.AsChatClient(native) != railguard.ChatClient(native) {
+			t.Error("expected native chat client to be returned unchanged")
+		}
+	})
+}
+
+func TestAsClient(t *testing.T) {
+	var got []railguard.Message
+	chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+		got = messages
+		return "ok", nil
+	})
+
+	out, err := railguard.AsClient(chat).Generate(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "ok" {
+		t.Errorf("expected 'ok', got %q", out)
+	}
+	if len(got) != 1 || got[0].Role != railguard.RoleUser || got[0].Content != "hello" {
+		t.Errorf("expected a single user message, got %+v", got)
+	}
+}
+
+func TestAsClientRoundTrip(t *testing.T) {
+	var got []railguard.Message
+	chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+		got = messages
+		return "ok", nil
+	})
+
+	messages := []railguard.Message{
+		{Role: railguard.RoleSystem, Content: "be brief"},
+		{Role: railguard.RoleUser, Content: "hi"},
+	}
+	roundTrip := railguard.AsChatClient(railguard.AsClient(chat))
+	if _, err := roundTrip.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected original chat client to receive the messages, got %+v", got)
+	}
+}
+
+// dualClient implements both Client and ChatClient.
+type dualClient struct{}
+
+func (c *dualClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return "generate", nil
+}
+
+func (c *dualClient) Chat(ctx context.Context, messages []railguard.Message) (string, error) {
+	return "chat", nil
+}
@@ -0,0 +1,297 @@
+// Package anthropic provides a railguard client for the Anthropic Messages API.
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/internal/httpx"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the Anthropic API.
+	DefaultBaseURL = "https://api.anthropic.com"
+
+	// DefaultMaxTokens is the max_tokens value sent when WithMaxTokens is not
+	// used. The Messages API requires an explicit limit.
+	DefaultMaxTokens = 1024
+
+	// apiVersion is the value of the anthropic-version header.
+	apiVersion = "2023-06-01"
+
+	// statusOverloaded is the status the API returns when it is overloaded.
+	statusOverloaded = 529
+)
+
+var (
+	// ErrEmptyResponse is returned when a response contains no text content.
+	ErrEmptyResponse = errors.New("anthropic: response contains no text content")
+
+	// ErrOverloaded matches an *APIError for an overloaded API (status 529).
+	ErrOverloaded = errors.New("anthropic: overloaded")
+
+	// ErrRateLimited matches an *APIError for a rate-limited request (status 429).
+	ErrRateLimited = errors.New("anthropic: rate limited")
+)
+
+// Client calls the /v1/messages endpoint of the Anthropic API.
+// It implements railguard.Client and railguard.ChatClient, and reports the
+// stop reason of each response with railguard.SetStopReason.
+type Client struct {
+	model       string
+	baseURL     string
+	apiKey      string
+	system      string
+	maxTokens   int
+	temperature *float64
+	httpClient  *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// New creates a client for the given model, e.g. "claude-3-5-haiku-latest".
+//
+// Example:
+//
+//	client := anthropic.New("claude-3-5-haiku-latest",
+//	    anthropic.WithAPIKey(os.Getenv("ANTHROPIC_API_KEY")),
+//	    anthropic.WithMaxTokens(2048),
+//	)
+func New(model string, opts ...Option) *Client {
+	c := &Client{
+		model:      model,
+		baseURL:    DefaultBaseURL,
+		maxTokens:  DefaultMaxTokens,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithBaseURL sets the base URL of the API.
+// Requests are sent to <base URL>/v1/messages.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAPIKey sets the API key sent in the x-api-key header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithSystem sets a system prompt sent with every request. System messages
+// passed to Chat are appended to it.
+func WithSystem(prompt string) Option {
+	return func(c *Client) {
+		c.system = prompt
+	}
+}
+
+// WithMaxTokens limits the number of tokens generated per response.
+// Defaults to DefaultMaxTokens.
+func WithMaxTokens(n int) Option {
+	return func(c *Client) {
+		c.maxTokens = n
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+// By default, the API's default temperature is used.
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// Model returns the configured model.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Generate sends the prompt as a single user message.
+func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.Chat(ctx, []railguard.Message{{Role: railguard.RoleUser, Content: prompt}})
+}
+
+// Chat sends the conversation to the Messages API and returns the text of the
+// response. System messages are moved to the system prompt, and consecutive
+// messages with the same role are merged, as the API requires user and
+// assistant turns to alternate. HTTP errors are returned as *APIError.
+func (c *Client) Chat(ctx context.Context, messages []railguard.Message) (string, error) {
+	req := messagesRequest{
+		Model:       c.model,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+	}
+
+	var system []string
+	if c.system != "" {
+		system = append(system, c.system)
+	}
+	for _, m := range messages {
+		if m.Role == railguard.RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == string(m.Role) {
+			req.Messages[n-1].Content += "\n\n" + m.Content
+			continue
+		}
+		req.Messages = append(req.Messages, message{Role: string(m.Role), Content: m.Content})
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	headers := map[string]string{"anthropic-version": apiVersion}
+	if c.apiKey != "" {
+		headers["x-api-key"] = c.apiKey
+	}
+
+	resp, err := httpx.PostJSON(ctx, c.httpClient, c.baseURL+"/v1/messages", headers, req)
+	if err != nil {
+		return "", fmt.Errorf("anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var body messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("anthropic: decoding response: %w", err)
+	}
+	railguard.SetStopReason(ctx, body.StopReason)
+
+	var text strings.Builder
+	found := false
+	for _, block := range body.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+			found = true
+		}
+	}
+	if !found {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+// APIError is returned when the API responds with a non-200 status.
+// It implements railguard.TemporaryError and railguard.RetryAfterError, so
+// the Guard retries overloaded and rate-limited requests, honoring the
+// retry-after header, but not invalid requests or authentication failures.
+//
+// Use errors.Is with ErrOverloaded or ErrRateLimited to check for those cases.
+type APIError struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int
+
+	// Type is the error type reported by the API, e.g. "overloaded_error".
+	Type string
+
+	// Message is the error message reported by the API, or the response body.
+	Message string
+
+	retryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("anthropic: status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the error is ErrOverloaded or ErrRateLimited.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrOverloaded:
+		return e.StatusCode == statusOverloaded
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed when retried:
+// true for 408, 429, 529, and other 5xx responses.
+func (e *APIError) Temporary() bool {
+	return httpx.Temporary(e.StatusCode)
+}
+
+// RetryAfter returns the delay requested by the server, or 0 if none.
+func (e *APIError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// newAPIError builds an APIError from an error response.
+func newAPIError(resp *http.Response) *APIError {
+	raw := httpx.ReadErrorBody(resp)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(raw)),
+		retryAfter: httpx.RetryAfter(resp.Header, time.Now()),
+	}
+
+	var body errorResponse
+	if json.Unmarshal(raw, &body) == nil && body.Error.Message != "" {
+		apiErr.Type = body.Error.Type
+		apiErr.Message = body.Error.Message
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// messagesRequest is the request body of the Messages API.
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature *float64  `json:"temperature,omitempty"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// messagesResponse is the response body of the Messages API.
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+// errorResponse is the error body returned by the Anthropic API.
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
@@ -0,0 +1,285 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/clients/anthropic"
+)
+
+// message writes a Messages API response with the given text and stop reason.
+func message(w http.ResponseWriter, text, stopReason string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":        "message",
+		"role":        "assistant",
+		"content":     []map[string]string{{"type": "text", "text": text}},
+		"stop_reason": stopReason,
+	})
+}
+
+func TestClientChat(t *testing.T) {
+	var got map[string]interface{}
+	var header http.Header
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		message(w, `{"answer": 42}`, "end_turn")
+	}))
+	defer server.Close()
+
+	client := anthropic.New("claude-3-5-haiku-latest",
+		anthropic.WithBaseURL(server.URL+"/"),
+		anthropic.WithAPIKey("sk-ant-test"),
+		anthropic.WithSystem("You are terse."),
+		anthropic.WithMaxTokens(256),
+		anthropic.WithTemperature(0),
+	)
+
+	out, err := client.Chat(context.Background(), []railguard.Message{
+		{Role: railguard.RoleSystem, Content: "Answer in JSON."},
+		{Role: railguard.RoleUser, Content: "Context: invoices."},
+		{Role: railguard.RoleUser, Content: "What is the answer?"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != `{"answer": 42}` {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	if path != "/v1/messages" {
+		t.Errorf("unexpected path: %q", path)
+	}
+	if header.Get("x-api-key") != "sk-ant-test" || header.Get("anthropic-version") == "" {
+		t.Errorf("unexpected headers: %v", header)
+	}
+	if got["model"] != "claude-3-5-haiku-latest" || got["max_tokens"] != float64(256) || got["temperature"] != float64(0) {
+		t.Errorf("unexpected request parameters: %v", got)
+	}
+	if got["system"] != "You are terse.\n\nAnswer in JSON." {
+		t.Errorf("unexpected system prompt: %q", got["system"])
+	}
+	messages, _ := got["messages"].([]interface{})
+	if len(messages) != 1 {
+		t.Fatalf("expected consecutive user messages to be merged, got %v", got["messages"])
+	}
+	if m := messages[0].(map[string]interface{}); m["role"] != "user" || m["content"] != "Context: invoices.\n\nWhat is the answer?" {
+		t.Errorf("unexpected message: %v", m)
+	}
+}
+
+func TestClientGenerate(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content": [{"type": "text", "text": "hel"}, {"type": "text", "text": "lo"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	client := anthropic.New("claude-3-5-haiku-latest", anthropic.WithBaseURL(server.URL))
+	out, err := client.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "hello" {
+		t.Errorf("expected text blocks to be joined, got %q", out)
+	}
+	if got["max_tokens"] != float64(anthropic.DefaultMaxTokens) {
+		t.Errorf("expected default max_tokens, got %v", got["max_tokens"])
+	}
+	for _, key := range []string{"system", "temperature"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("expected %s to be omitted by default", key)
+		}
+	}
+	if client.Model() != "claude-3-5-haiku-latest" {
+		t.Errorf("unexpected model: %q", client.Model())
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		header        http.Header
+		body          string
+		wantTemporary bool
+		wantRetry     time.Duration
+		wantType      string
+		wantMessage   string
+		wantIs        error
+	}{
+		{
+			name:          "overloaded",
+			status:        529,
+			body:          `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			wantTemporary: true,
+			wantType:      "overloaded_error",
+			wantMessage:   "Overloaded",
+			wantIs:        anthropic.ErrOverloaded,
+		},
+		{
+			name:          "rate limited",
+			status:        http.StatusTooManyRequests,
+			header:        http.Header{"Retry-After": {"3"}},
+			body:          `{"type": "error", "error": {"type": "rate_limit_error", "message": "Number of requests has exceeded your rate limit"}}`,
+			wantTemporary: true,
+			wantRetry:     3 * time.Second,
+			wantType:      "rate_limit_error",
+			wantMessage:   "Number of requests has exceeded your rate limit",
+			wantIs:        anthropic.ErrRateLimited,
+		},
+		{
+			name:          "invalid request",
+			status:        http.StatusBadRequest,
+			body:          `{"type": "error", "error": {"type": "invalid_request_error", "message": "max_tokens: field required"}}`,
+			wantTemporary: false,
+			wantType:      "invalid_request_error",
+			wantMessage:   "max_tokens: field required",
+		},
+		{
+			name:          "unauthorized",
+			status:        http.StatusUnauthorized,
+			body:          "invalid x-api-key",
+			wantTemporary: false,
+			wantMessage:   "invalid x-api-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, values := range tt.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := anthropic.New("claude-3-5-haiku-latest", anthropic.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+
+			var apiErr *anthropic.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Type != tt.wantType || apiErr.Message != tt.wantMessage {
+				t.Errorf("unexpected error: %+v", apiErr)
+			}
+			if apiErr.Temporary() != tt.wantTemporary {
+				t.Errorf("expected Temporary() = %v", tt.wantTemporary)
+			}
+			if apiErr.RetryAfter() != tt.wantRetry {
+				t.Errorf("expected RetryAfter() = %v, got %v", tt.wantRetry, apiErr.RetryAfter())
+			}
+			for _, sentinel := range []error{anthropic.ErrOverloaded, anthropic.ErrRateLimited} {
+				if errors.Is(err, sentinel) != (sentinel == tt.wantIs) {
+					t.Errorf("unexpected errors.Is(err, %v) = %v", sentinel, errors.Is(err, sentinel))
+				}
+			}
+		})
+	}
+
+	t.Run("no text content", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"content": [], "stop_reason": "end_turn"}`))
+		}))
+		defer server.Close()
+
+		_, err := anthropic.New("claude-3-5-haiku-latest", anthropic.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+		if !errors.Is(err, anthropic.ErrEmptyResponse) {
+			t.Errorf("expected ErrEmptyResponse, got %v", err)
+		}
+	})
+}
+
+func TestClientWithGuard(t *testing.T) {
+	type Response struct {
+		Answer string `json:"answer"`
+	}
+
+	t.Run("retries overloaded and reports stop reason", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch calls.Add(1) {
+			case 1:
+				w.WriteHeader(529)
+				_, _ = w.Write([]byte(`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`))
+			case 2:
+				message(w, `{"answer": "tru`, "max_tokens")
+			default:
+				message(w, `{"answer": "ok"}`, "end_turn")
+			}
+		}))
+		defer server.Close()
+
+		g, err := railguard.New(
+			railguard.WithClient(anthropic.New("claude-3-5-haiku-latest", anthropic.WithBaseURL(server.URL))),
+			railguard.WithSchema(&Response{}),
+			railguard.WithRetry(railguard.RetryConfig{
+				MaxAttempts:  3,
+				InitialDelay: time.Millisecond,
+				MaxDelay:     time.Millisecond,
+				Multiplier:   1,
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Attempts != 3 || result.Parsed.(*Response).Answer != "ok" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if result.Metadata.StopReason != "end_turn" {
+			t.Errorf("expected stop reason end_turn, got %q", result.Metadata.StopReason)
+		}
+		trace := result.Metadata.Trace
+		if !errors.Is(trace[0].Err, anthropic.ErrOverloaded) || trace[0].StopReason != "" {
+			t.Errorf("unexpected first record: %+v", trace[0])
+		}
+		if trace[1].StopReason != "max_tokens" || trace[1].Stage != railguard.StageSchema {
+			t.Errorf("unexpected second record: %+v", trace[1])
+		}
+	})
+
+	t.Run("does not retry invalid requests", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type": "error", "error": {"type": "invalid_request_error", "message": "bad"}}`))
+		}))
+		defer server.Close()
+
+		g, _ := railguard.New(
+			railguard.WithClient(anthropic.New("claude-3-5-haiku-latest", anthropic.WithBaseURL(server.URL))),
+			railguard.WithMaxRetries(3),
+		)
+
+		_, err := g.Run(context.Background(), "test")
+		var apiErr *anthropic.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 APIError, got %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 request, got %d", calls.Load())
+		}
+	})
+}
@@ -0,0 +1,327 @@
+// Package ollama provides a railguard client for the local HTTP API of Ollama.
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/internal/httpx"
+)
+
+// DefaultBaseURL is the address Ollama listens on by default.
+const DefaultBaseURL = "http://localhost:11434"
+
+// ErrIncompleteStream is returned when a response ends before Ollama reports
+// that generation is done, e.g. because the server stopped.
+var ErrIncompleteStream = errors.New("ollama: response ended before generation was done")
+
+// Client calls the /api/generate and /api/chat endpoints of an Ollama server.
+// It implements railguard.Client, railguard.ChatClient, and
+// railguard.StreamingClient, and reports Ollama's done_reason with
+// railguard.SetStopReason.
+type Client struct {
+	model       string
+	baseURL     string
+	jsonMode    bool
+	temperature *float64
+	maxTokens   int
+	httpClient  *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// New creates a client for the given model, e.g. "llama3.1".
+//
+// Example:
+//
+//	client := ollama.New("llama3.1", ollama.WithJSONMode())
+func New(model string, opts ...Option) *Client {
+	c := &Client{
+		model:      model,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithBaseURL sets the address of the Ollama server.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithJSONMode sets format "json", constraining the model to produce valid
+// JSON, which pairs well with railguard.WithSchema.
+func WithJSONMode() Option {
+	return func(c *Client) {
+		c.jsonMode = true
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+// By default, the model's default temperature is used.
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithMaxTokens limits the number of tokens generated per response
+// (Ollama's num_predict option).
+func WithMaxTokens(n int) Option {
+	return func(c *Client) {
+		c.maxTokens = n
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests. Local models can be
+// slow to load, so prefer Guard timeouts over a short http.Client timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// Model returns the configured model.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Generate sends the prompt to /api/generate and returns the full response.
+func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.post(ctx, "/api/generate", generateRequest{
+		request: c.request(false),
+		Prompt:  prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("ollama: decoding response: %w", err)
+	}
+	if err := body.check(); err != nil {
+		return "", err
+	}
+	railguard.SetStopReason(ctx, body.DoneReason)
+	return body.Response, nil
+}
+
+// Chat sends the conversation to /api/chat and returns the full response.
+func (c *Client) Chat(ctx context.Context, messages []railguard.Message) (string, error) {
+	req := chatRequest{
+		request:  c.request(false),
+		Messages: make([]message, len(messages)),
+	}
+	for i, m := range messages {
+		req.Messages[i] = message{Role: string(m.Role), Content: m.Content}
+	}
+
+	resp, err := c.post(ctx, "/api/chat", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("ollama: decoding response: %w", err)
+	}
+	if err := body.check(); err != nil {
+		return "", err
+	}
+	railguard.SetStopReason(ctx, body.DoneReason)
+	return body.Message.Content, nil
+}
+
+// GenerateStream sends the prompt to /api/generate with streaming enabled and
+// delivers the NDJSON response as chunks. Errors reported by Ollama during
+// generation end the stream with a chunk carrying the error.
+func (c *Client) GenerateStream(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+	resp, err := c.post(ctx, "/api/generate", generateRequest{
+		request: c.request(true),
+		Prompt:  prompt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan railguard.Chunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		send := func(chunk railguard.Chunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var body response
+			if err := dec.Decode(&body); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = ErrIncompleteStream
+				} else {
+					err = fmt.Errorf("ollama: decoding response: %w", err)
+				}
+				// A canceled request surfaces as a read error; the caller knows why
+				if ctx.Err() == nil {
+					send(railguard.Chunk{Err: err})
+				}
+				return
+			}
+			if err := body.check(); err != nil {
+				send(railguard.Chunk{Err: err})
+				return
+			}
+			if body.Response != "" && !send(railguard.Chunk{Text: body.Response}) {
+				return
+			}
+			if body.Done {
+				railguard.SetStopReason(ctx, body.DoneReason)
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// request returns the fields shared by generate and chat requests.
+func (c *Client) request(stream bool) request {
+	req := request{Model: c.model, Stream: stream}
+	if c.jsonMode {
+		req.Format = "json"
+	}
+	if c.temperature != nil || c.maxTokens > 0 {
+		req.Options = &modelOptions{Temperature: c.temperature, NumPredict: c.maxTokens}
+	}
+	return req
+}
+
+// post sends the request and returns the response if its status is 200.
+// Other statuses are returned as *APIError.
+func (c *Client) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	resp, err := httpx.PostJSON(ctx, c.httpClient, c.baseURL+path, nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// APIError is returned when Ollama responds with a non-200 status.
+// It implements railguard.TemporaryError and railguard.RetryAfterError, so
+// the Guard retries server errors, e.g. while a model is loading, but not
+// client errors such as an unknown model.
+type APIError struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int
+
+	// Message is the error message reported by Ollama, or the response body.
+	Message string
+
+	retryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ollama: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed when retried:
+// true for 408, 429, and 5xx responses.
+func (e *APIError) Temporary() bool {
+	return httpx.Temporary(e.StatusCode)
+}
+
+// RetryAfter returns the delay requested by the server, or 0 if none.
+func (e *APIError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// newAPIError builds an APIError from an error response.
+func newAPIError(resp *http.Response) *APIError {
+	raw := httpx.ReadErrorBody(resp)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(raw)),
+		retryAfter: httpx.RetryAfter(resp.Header, time.Now()),
+	}
+
+	var body response
+	if json.Unmarshal(raw, &body) == nil && body.Error != "" {
+		apiErr.Message = body.Error
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// request holds the fields shared by /api/generate and /api/chat requests.
+type request struct {
+	Model   string        `json:"model"`
+	Stream  bool          `json:"stream"`
+	Format  string        `json:"format,omitempty"`
+	Options *modelOptions `json:"options,omitempty"`
+}
+
+type generateRequest struct {
+	request
+	Prompt string `json:"prompt"`
+}
+
+type chatRequest struct {
+	request
+	Messages []message `json:"messages"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type modelOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+// response is a response of /api/generate or /api/chat, or one line of a
+// streamed response.
+type response struct {
+	Response   string  `json:"response"`
+	Message    message `json:"message"`
+	Done       bool    `json:"done"`
+	DoneReason string  `json:"done_reason"`
+	Error      string  `json:"error"`
+}
+
+// check returns the error reported in the response body, if any.
+func (r *response) check() error {
+	if r.Error != "" {
+		return fmt.Errorf("ollama: %s", r.Error)
+	}
+	return nil
+}
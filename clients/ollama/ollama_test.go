@@ -0,0 +1,405 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/clients/ollama"
+)
+
+// streamLines writes NDJSON lines, flushing after each one like Ollama does.
+func streamLines(w http.ResponseWriter, lines ...string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// tokens returns the NDJSON lines of a streamed /api/generate response.
+func tokens(parts ...string) []string {
+	lines := make([]string, 0, len(parts)+1)
+	for _, part := range parts {
+		encoded, _ := json.Marshal(part)
+		lines = append(lines, fmt.Sprintf(`{"model":"llama3.1","response":%s,"done":false}`, encoded))
+	}
+	return append(lines, `{"model":"llama3.1","response":"","done":true,"done_reason":"stop"}`)
+}
+
+func TestClientGenerate(t *testing.T) {
+	var got map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.1","response":"{\"answer\": 42}","done":true,"done_reason":"stop"}`))
+	}))
+	defer server.Close()
+
+	client := ollama.New("llama3.1",
+		ollama.WithBaseURL(server.URL+"/"),
+		ollama.WithJSONMode(),
+		ollama.WithTemperature(0),
+		ollama.WithMaxTokens(128),
+	)
+
+	out, err := client.Generate(context.Background(), "What is the answer?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != `{"answer": 42}` {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	if path != "/api/generate" {
+		t.Errorf("unexpected path: %q", path)
+	}
+	if got["model"] != "llama3.1" || got["prompt"] != "What is the answer?" || got["stream"] != false || got["format"] != "json" {
+		t.Errorf("unexpected request: %v", got)
+	}
+	options, _ := got["options"].(map[string]interface{})
+	if options["temperature"] != float64(0) || options["num_predict"] != float64(128) {
+		t.Errorf("unexpected model options: %v", got["options"])
+	}
+	if client.Model() != "llama3.1" {
+		t.Errorf("unexpected model: %q", client.Model())
+	}
+}
+
+func TestClientChat(t *testing.T) {
+	var got map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"model":"llama3.1","message":{"role":"assistant","content":"hello"},"done":true,"done_reason":"stop"}`))
+	}))
+	defer server.Close()
+
+	client := ollama.New("llama3.1", ollama.WithBaseURL(server.URL))
+	out, err := client.Chat(context.Background(), []railguard.Message{
+		{Role: railguard.RoleSystem, Content: "Be brief."},
+		{Role: railguard.RoleUser, Content: "hi"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "hello" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	if path != "/api/chat" {
+		t.Errorf("unexpected path: %q", path)
+	}
+	messages, _ := got["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("unexpected messages: %v", got["messages"])
+	}
+	for _, key := range []string{"format", "options"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("expected %s to be omitted by default", key)
+		}
+	}
+}
+
+func TestClientGenerateStream(t *testing.T) {
+	t.Run("delivers tokens", func(t *testing.T) {
+		var got map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			streamLines(w, tokens("Hel", "lo", " world")...)
+		}))
+		defer server.Close()
+
+		chunks, err := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).GenerateStream(context.Background(), "hi")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var texts []string
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				t.Fatalf("unexpected chunk error: %v", chunk.Err)
+			}
+			texts = append(texts, chunk.Text)
+		}
+		if strings.Join(texts, "|") != "Hel|lo| world" {
+			t.Errorf("unexpected chunks: %q", texts)
+		}
+		if got["stream"] != true {
+			t.Errorf("expected stream to be requested, got %v", got["stream"])
+		}
+	})
+
+	t.Run("error during generation", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w,
+				`{"model":"llama3.1","response":"Hel","done":false}`,
+				`{"error":"model runner has unexpectedly stopped"}`,
+			)
+		}))
+		defer server.Close()
+
+		chunks, err := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).GenerateStream(context.Background(), "hi")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var last railguard.Chunk
+		n := 0
+		for chunk := range chunks {
+			last = chunk
+			n++
+		}
+		if n != 2 || last.Err == nil || !strings.Contains(last.Err.Error(), "unexpectedly stopped") {
+			t.Errorf("expected text chunk followed by error chunk, got %d chunks ending with %+v", n, last)
+		}
+	})
+
+	t.Run("stream ends before done", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w, `{"model":"llama3.1","response":"Hel","done":false}`)
+		}))
+		defer server.Close()
+
+		chunks, _ := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).GenerateStream(context.Background(), "hi")
+		var last railguard.Chunk
+		for chunk := range chunks {
+			last = chunk
+		}
+		if !errors.Is(last.Err, ollama.ErrIncompleteStream) {
+			t.Errorf("expected ErrIncompleteStream, got %v", last.Err)
+		}
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w, `{"model":"llama3.1","response":"Hel","done":false}`)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		chunks, err := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).GenerateStream(ctx, "hi")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk := <-chunks; chunk.Text != "Hel" {
+			t.Fatalf("unexpected first chunk: %+v", chunk)
+		}
+		cancel()
+
+		select {
+		case chunk, ok := <-chunks:
+			if ok {
+				t.Errorf("expected channel to close without further chunks, got %+v", chunk)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("stream did not stop after cancellation")
+		}
+	})
+
+	t.Run("model not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model \"llama9\" not found, try pulling it first"}`))
+		}))
+		defer server.Close()
+
+		_, err := ollama.New("llama9", ollama.WithBaseURL(server.URL)).GenerateStream(context.Background(), "hi")
+		var apiErr *ollama.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Temporary() {
+			t.Fatalf("expected permanent 404 APIError, got %v", err)
+		}
+		if apiErr.Message != `model "llama9" not found, try pulling it first` {
+			t.Errorf("unexpected message: %q", apiErr.Message)
+		}
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantTemporary bool
+		wantMessage   string
+	}{
+		{
+			name:          "model not found",
+			status:        http.StatusNotFound,
+			body:          `{"error":"model \"llama9\" not found, try pulling it first"}`,
+			wantTemporary: false,
+			wantMessage:   `model "llama9" not found, try pulling it first`,
+		},
+		{
+			name:          "bad request",
+			status:        http.StatusBadRequest,
+			body:          `{"error":"invalid format"}`,
+			wantTemporary: false,
+			wantMessage:   "invalid format",
+		},
+		{
+			name:          "server busy",
+			status:        http.StatusServiceUnavailable,
+			body:          "server busy",
+			wantTemporary: true,
+			wantMessage:   "server busy",
+		},
+		{
+			name:          "server error",
+			status:        http.StatusInternalServerError,
+			wantTemporary: true,
+			wantMessage:   "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+
+			var apiErr *ollama.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage {
+				t.Errorf("unexpected error: %+v", apiErr)
+			}
+			if apiErr.Temporary() != tt.wantTemporary {
+				t.Errorf("expected Temporary() = %v", tt.wantTemporary)
+			}
+		})
+	}
+
+	t.Run("error in response body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"error":"context length exceeded"}`))
+		}))
+		defer server.Close()
+
+		_, err := ollama.New("llama3.1", ollama.WithBaseURL(server.URL)).Chat(context.Background(), []railguard.Message{{Role: railguard.RoleUser, Content: "hi"}})
+		if err == nil || !strings.Contains(err.Error(), "context length exceeded") {
+			t.Errorf("expected reported error, got %v", err)
+		}
+	})
+}
+
+func TestClientWithGuard(t *testing.T) {
+	type Response struct {
+		Answer string `json:"answer"`
+	}
+
+	t.Run("run retries server errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if r.URL.Path != "/api/chat" {
+				t.Errorf("expected the Guard to use /api/chat, got %s", r.URL.Path)
+			}
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"{\"answer\": \"ok\"}"},"done":true,"done_reason":"stop"}`))
+		}))
+		defer server.Close()
+
+		g, err := railguard.New(
+			railguard.WithClient(ollama.New("llama3.1", ollama.WithBaseURL(server.URL), ollama.WithJSONMode())),
+			railguard.WithSchema(&Response{}),
+			railguard.WithRetry(railguard.RetryConfig{
+				MaxAttempts:  3,
+				InitialDelay: time.Millisecond,
+				MaxDelay:     time.Millisecond,
+				Multiplier:   1,
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Attempts != 2 || result.Parsed.(*Response).Answer != "ok" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if result.Metadata.StopReason != "stop" {
+			t.Errorf("expected stop reason stop, got %q", result.Metadata.StopReason)
+		}
+	})
+
+	t.Run("run does not retry unknown models", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model not found"}`))
+		}))
+		defer server.Close()
+
+		g, _ := railguard.New(
+			railguard.WithClient(ollama.New("llama9", ollama.WithBaseURL(server.URL))),
+			railguard.WithMaxRetries(3),
+		)
+
+		if _, err := g.Run(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 request, got %d", calls.Load())
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w, tokens(`{"answer"`, `: "ok"}`)...)
+		}))
+		defer server.Close()
+
+		g, _ := railguard.New(
+			railguard.WithClient(ollama.New("llama3.1", ollama.WithBaseURL(server.URL), ollama.WithJSONMode())),
+			railguard.WithSchema(&Response{}),
+		)
+
+		var chunks []string
+		result, err := g.Stream(context.Background(), "test", func(chunk string) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(chunks) != 2 || result.Parsed.(*Response).Answer != "ok" {
+			t.Errorf("unexpected stream result: chunks %q, result %+v", chunks, result)
+		}
+		if result.Metadata.StopReason != "stop" {
+			t.Errorf("expected stop reason stop, got %q", result.Metadata.StopReason)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+// Package openai provides a railguard client for OpenAI-compatible chat
+// completion APIs, including servers such as vLLM, LM Studio, and llama.cpp.
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/internal/httpx"
+)
+
+// DefaultBaseURL is the base URL of the OpenAI API.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// ErrEmptyResponse is returned when a response contains no choices.
+var ErrEmptyResponse = errors.New("openai: response contains no choices")
+
+// Client calls the /chat/completions endpoint of an OpenAI-compatible API.
+// It implements railguard.Client and railguard.ChatClient.
+type Client struct {
+	model       string
+	baseURL     string
+	apiKey      string
+	temperature *float64
+	maxTokens   int
+	jsonMode    bool
+	httpClient  *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// New creates a client for the given model, e.g. "gpt-4o-mini".
+//
+// Example:
+//
+//	client := openai.New("gpt-4o-mini",
+//	    openai.WithAPIKey(os.Getenv("OPENAI_API_KEY")),
+//	    openai.WithJSONMode(),
+//	)
+func New(model string, opts ...Option) *Client {
+	c := &Client{
+		model:      model,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithBaseURL sets the base URL of the API, e.g. "http://localhost:8000/v1"
+// for a local vLLM server. Requests are sent to <base URL>/chat/completions.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAPIKey sets the API key sent as a bearer token.
+// Local servers usually do not need one.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+// By default, the server's default temperature is used.
+func WithTemperature(temperature float64) Option {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithMaxTokens limits the number of tokens generated per response.
+func WithMaxTokens(n int) Option {
+	return func(c *Client) {
+		c.maxTokens = n
+	}
+}
+
+// WithJSONMode requests JSON output using response_format json_object,
+// which pairs well with railguard.WithSchema.
+func WithJSONMode() Option {
+	return func(c *Client) {
+		c.jsonMode = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// Model returns the configured model.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Generate sends the prompt as a single user message.
+func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.Chat(ctx, []railguard.Message{{Role: railguard.RoleUser, Content: prompt}})
+}
+
+// Chat sends the conversation to the chat completions endpoint and returns
+// the content of the first choice. HTTP errors are returned as *APIError.
+func (c *Client) Chat(ctx context.Context, messages []railguard.Message) (string, error) {
+	req := chatRequest{
+		Model:       c.model,
+		Messages:    make([]chatMessage, len(messages)),
+		Temperature: c.temperature,
+		MaxTokens:   c.maxTokens,
+	}
+	for i, m := range messages {
+		req.Messages[i] = chatMessage{Role: string(m.Role), Content: m.Content}
+	}
+	if c.jsonMode {
+		req.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+
+	resp, err := httpx.PostJSON(ctx, c.httpClient, c.baseURL+"/chat/completions", headers, req)
+	if err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var body chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("openai: decoding response: %w", err)
+	}
+	if len(body.Choices) == 0 {
+		return "", ErrEmptyResponse
+	}
+	return body.Choices[0].Message.Content, nil
+}
+
+// APIError is returned when the API responds with a non-200 status.
+// It implements railguard.TemporaryError and railguard.RetryAfterError, so
+// the Guard retries rate limits and server errors but not client errors
+// such as an invalid API key or an unknown model.
+type APIError struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int
+
+	// Type is the error type reported by the API, if any.
+	Type string
+
+	// Code is the error code reported by the API, if any.
+	Code string
+
+	// Message is the error message reported by the API, or the response body.
+	Message string
+
+	retryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openai: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed when retried:
+// true for 408, 429, and 5xx responses.
+func (e *APIError) Temporary() bool {
+	return httpx.Temporary(e.StatusCode)
+}
+
+// RetryAfter returns the delay requested by the server, or 0 if none.
+func (e *APIError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// newAPIError builds an APIError from an error response.
+func newAPIError(resp *http.Response) *APIError {
+	raw := httpx.ReadErrorBody(resp)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(raw)),
+		retryAfter: httpx.RetryAfter(resp.Header, time.Now()),
+	}
+
+	var body errorResponse
+	if json.Unmarshal(raw, &body) == nil && body.Error.Message != "" {
+		apiErr.Type = body.Error.Type
+		apiErr.Message = body.Error.Message
+		if body.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(body.Error.Code)
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// chatRequest is the request body of the chat completions endpoint.
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatResponse is the response body of the chat completions endpoint.
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// errorResponse is the error body returned by OpenAI-compatible APIs.
+// Code is a string for OpenAI and a number for some compatible servers.
+type errorResponse struct {
+	Error struct {
+		Message string      `json:"message"`
+		Type    string      `json:"type"`
+		Code    interface{} `json:"code"`
+	} `json:"error"`
+}
@@ -0,0 +1,272 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/clients/openai"
+)
+
+// completion writes a chat completion response with the given content.
+func completion(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []map[string]interface{}{
+			{"message": map[string]string{"role": "assistant", "content": content}, "finish_reason": "stop"},
+		},
+	})
+}
+
+func TestClientChat(t *testing.T) {
+	var got map[string]interface{}
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		completion(w, `{"answer": 42}`)
+	}))
+	defer server.Close()
+
+	client := openai.New("gpt-4o-mini",
+		openai.WithBaseURL(server.URL+"/v1/"),
+		openai.WithAPIKey("sk-test"),
+		openai.WithTemperature(0),
+		openai.WithMaxTokens(256),
+		openai.WithJSONMode(),
+	)
+
+	out, err := client.Chat(context.Background(), []railguard.Message{
+		{Role: railguard.RoleSystem, Content: "Answer in JSON."},
+		{Role: railguard.RoleUser, Content: "What is the answer?"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != `{"answer": 42}` {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	if path != "/v1/chat/completions" {
+		t.Errorf("unexpected path: %q", path)
+	}
+	if auth != "Bearer sk-test" {
+		t.Errorf("unexpected authorization header: %q", auth)
+	}
+	if got["model"] != "gpt-4o-mini" || got["temperature"] != float64(0) || got["max_tokens"] != float64(256) {
+		t.Errorf("unexpected request parameters: %v", got)
+	}
+	if format, _ := got["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+		t.Errorf("expected json_object response format, got %v", got["response_format"])
+	}
+	messages, _ := got["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %v", got["messages"])
+	}
+	if first := messages[0].(map[string]interface{}); first["role"] != "system" || first["content"] != "Answer in JSON." {
+		t.Errorf("unexpected system message: %v", first)
+	}
+}
+
+func TestClientGenerate(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		completion(w, "hello")
+	}))
+	defer server.Close()
+
+	client := openai.New("local-model", openai.WithBaseURL(server.URL))
+	out, err := client.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "hello" {
+		t.Errorf("unexpected output: %q", out)
+	}
+	if auth != "" {
+		t.Errorf("expected no authorization header without API key, got %q", auth)
+	}
+	for _, key := range []string{"temperature", "max_tokens", "response_format"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("expected %s to be omitted by default", key)
+		}
+	}
+	if client.Model() != "local-model" {
+		t.Errorf("unexpected model: %q", client.Model())
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		header        http.Header
+		body          string
+		wantTemporary bool
+		wantRetry     time.Duration
+		wantMessage   string
+		wantCode      string
+	}{
+		{
+			name:          "rate limited",
+			status:        http.StatusTooManyRequests,
+			header:        http.Header{"Retry-After": {"2"}},
+			body:          `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			wantTemporary: true,
+			wantRetry:     2 * time.Second,
+			wantMessage:   "Rate limit reached",
+			wantCode:      "rate_limit_exceeded",
+		},
+		{
+			name:          "server error",
+			status:        http.StatusBadGateway,
+			body:          "upstream unavailable",
+			wantTemporary: true,
+			wantMessage:   "upstream unavailable",
+		},
+		{
+			name:          "invalid model",
+			status:        http.StatusBadRequest,
+			body:          `{"error": {"message": "The model does not exist", "type": "invalid_request_error", "code": 400}}`,
+			wantTemporary: false,
+			wantMessage:   "The model does not exist",
+			wantCode:      "400",
+		},
+		{
+			name:          "unauthorized",
+			status:        http.StatusUnauthorized,
+			wantTemporary: false,
+			wantMessage:   "Unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, values := range tt.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := openai.New("gpt-4o-mini", openai.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+
+			var apiErr *openai.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage || apiErr.Code != tt.wantCode {
+				t.Errorf("unexpected error: %+v", apiErr)
+			}
+			if apiErr.Temporary() != tt.wantTemporary {
+				t.Errorf("expected Temporary() = %v", tt.wantTemporary)
+			}
+			if apiErr.RetryAfter() != tt.wantRetry {
+				t.Errorf("expected RetryAfter() = %v, got %v", tt.wantRetry, apiErr.RetryAfter())
+			}
+
+			var temporary railguard.TemporaryError
+			if !errors.As(err, &temporary) {
+				t.Error("expected APIError to implement railguard.TemporaryError")
+			}
+		})
+	}
+
+	t.Run("empty choices", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"choices": []}`))
+		}))
+		defer server.Close()
+
+		_, err := openai.New("gpt-4o-mini", openai.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+		if !errors.Is(err, openai.ErrEmptyResponse) {
+			t.Errorf("expected ErrEmptyResponse, got %v", err)
+		}
+	})
+
+	t.Run("invalid response body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`not json`))
+		}))
+		defer server.Close()
+
+		_, err := openai.New("gpt-4o-mini", openai.WithBaseURL(server.URL)).Generate(context.Background(), "hi")
+		if err == nil {
+			t.Error("expected decoding error")
+		}
+	})
+}
+
+func TestClientWithGuard(t *testing.T) {
+	t.Run("retries rate limits", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After-Ms", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			completion(w, `{"answer": "ok"}`)
+		}))
+		defer server.Close()
+
+		type Response struct {
+			Answer string `json:"answer"`
+		}
+		g, err := railguard.New(
+			railguard.WithClient(openai.New("gpt-4o-mini", openai.WithBaseURL(server.URL), openai.WithJSONMode())),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Attempts != 2 || result.Parsed.(*Response).Answer != "ok" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if backoff := result.Metadata.Trace[1].Backoff; backoff != time.Millisecond {
+			t.Errorf("expected Retry-After-Ms delay, got %v", backoff)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		g, _ := railguard.New(
+			railguard.WithClient(openai.New("gpt-4o-mini", openai.WithBaseURL(server.URL))),
+			railguard.WithMaxRetries(3),
+		)
+
+		_, err := g.Run(context.Background(), "test")
+		var apiErr *openai.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected 401 APIError, got %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("expected 1 request, got %d", calls.Load())
+		}
+	})
+}
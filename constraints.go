@@ -0,0 +1,405 @@
+package railguard
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Violation describes a field of a parsed output that broke a constraint
+// declared in its railguard struct tag.
+type Violation struct {
+	// Path locates the field in the output, e.g. "invoices[2].amount".
+	Path string
+
+	// Constraint is the name of the broken constraint, e.g. "max" or "enum".
+	Constraint string
+
+	// Message describes the violation, e.g. "must be at most 100, got 140".
+	// It does not quote strings from the output, such as the value that
+	// broke an enum or pattern constraint, so that it can be logged.
+	Message string
+}
+
+// String returns the violation as "path: message".
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ConstraintError is returned by Schema.Unmarshal when a decoded output
+// violates the constraints of its railguard struct tags. It lists every
+// violation, not just the first one.
+type ConstraintError struct {
+	// Violations lists the violations in field order.
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *ConstraintError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "constraint violations: " + strings.Join(parts, "; ")
+}
+
+// fieldConstraints holds the parsed railguard tag of a struct field.
+type fieldConstraints struct {
+	// required means the key must be present and not null.
+	required bool
+
+	// optional means the key may be missing, overriding the implicit
+	// required rule of the schema.
+	optional bool
+
+	// rules are checked against the decoded value of the field.
+	rules []constraintRule
+}
+
+// constraintRule is a single constraint of a railguard tag.
+type constraintRule struct {
+	// name is the constraint name, e.g. "min".
+	name string
+
+	// elem is true if the rule applies to each element of a slice or array
+	// rather than to the field itself.
+	elem bool
+
+	// check returns a message describing the violation, or "" if v passes.
+	// v is never a pointer.
+	check func(v reflect.Value) string
+
+	// schema applies the rule to the JSON Schema of the field.
+	schema func(s *jsonSchema)
+}
+
+// constraintCache caches the field constraints of struct types, aligned with
+// structFields.
+var constraintCache sync.Map
+
+// constraintsOf returns the constraints of the fields of struct type t,
+// aligned with structFields(t). Tags were validated by NewSchema, so invalid
+// tags are ignored here.
+func constraintsOf(t reflect.Type) []fieldConstraints {
+	if cached, ok := constraintCache.Load(t); ok {
+		return cached.([]fieldConstraints)
+	}
+
+	fields := structFields(t)
+	constraints := make([]fieldConstraints, len(fields))
+	for i, f := range fields {
+		constraints[i], _ = parseConstraints(f)
+	}
+
+	cached, _ := constraintCache.LoadOrStore(t, constraints)
+	return cached.([]fieldConstraints)
+}
+
+// validateConstraintTags parses the railguard tags of t and every struct type
+// reachable from it, returning the first invalid tag.
+func validateConstraintTags(t reflect.Type, visited map[reflect.Type]bool) error {
+	t = indirect(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return validateConstraintTags(t.Elem(), visited)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	if visited[t] || t == timeType {
+		return nil
+	}
+	visited[t] = true
+
+	for _, f := range structFields(t) {
+		if _, err := parseConstraints(f); err != nil {
+			return fmt.Errorf("%w: %v.%s: %v", ErrInvalidConstraint, t, f.goName, err)
+		}
+		if err := validateConstraintTags(f.typ, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseConstraints parses the railguard tag of a field, e.g.
+// `railguard:"required,min=0,max=100"`. The pattern constraint takes the rest
+// of the tag, so that the expression may contain commas; it must come last.
+func parseConstraints(f schemaField) (fieldConstraints, error) {
+	var fc fieldConstraints
+
+	tag := f.tag.Get("railguard")
+	for tag != "" {
+		var item string
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "pattern=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(item), "=")
+
+		switch name {
+		case "":
+			continue
+		case "required":
+			fc.required = true
+			continue
+		case "optional":
+			fc.optional = true
+			continue
+		}
+		if !hasArg && name != "nonempty" {
+			return fc, fmt.Errorf("constraint %q needs a value", name)
+		}
+
+		rule, err := newRule(name, arg, f)
+		if err != nil {
+			return fc, err
+		}
+		fc.rules = append(fc.rules, rule)
+	}
+	if fc.required && fc.optional {
+		return fc, fmt.Errorf("constraints \"required\" and \"optional\" are exclusive")
+	}
+	return fc, nil
+}
+
+// newRule builds the rule name=arg for field f, checking that it applies to
+// the field's type.
+func newRule(name, arg string, f schemaField) (constraintRule, error) {
+	t := indirect(f.typ)
+	rule := constraintRule{name: name}
+
+	// Value rules on a slice of scalars apply to each element
+	valueType := t
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && name != "nonempty" && !isLengthRule(name) {
+		valueType = indirect(t.Elem())
+		rule.elem = true
+	}
+
+	switch name {
+	case "nonempty":
+		rule.check = func(v reflect.Value) string {
+			if v.IsZero() || (hasLen(v.Kind()) && v.Len() == 0) {
+				return "must not be empty"
+			}
+			return ""
+		}
+		rule.schema = func(s *jsonSchema) {
+			switch {
+			case isType(s, "string"):
+				s.MinLength = maxPtr(s.MinLength, 1)
+			case isType(s, "array"):
+				s.MinItems = maxPtr(s.MinItems, 1)
+			case isType(s, "object") && s.Properties == nil:
+				s.MinProperties = maxPtr(s.MinProperties, 1)
+			}
+		}
+
+	case "min", "max":
+		if !isNumber(valueType.Kind()) {
+			return rule, fmt.Errorf("constraint %q needs a number field, got %v", name, f.typ)
+		}
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return rule, fmt.Errorf("constraint %q: invalid number %q", name, arg)
+		}
+		if name == "min" {
+			rule.check = func(v reflect.Value) string {
+				if n := numberOf(v); n < limit {
+					return fmt.Sprintf("must be at least %s, got %s", arg, formatNumber(v))
+				}
+				return ""
+			}
+			rule.schema = func(s *jsonSchema) { s.Minimum = &limit }
+		} else {
+			rule.check = func(v reflect.Value) string {
+				if n := numberOf(v); n > limit {
+					return fmt.Sprintf("must be at most %s, got %s", arg, formatNumber(v))
+				}
+				return ""
+			}
+			rule.schema = func(s *jsonSchema) { s.Maximum = &limit }
+		}
+
+	case "minLen", "maxLen", "len":
+		if !hasLen(t.Kind()) {
+			return rule, fmt.Errorf("constraint %q needs a string, slice, array, or map field, got %v", name, f.typ)
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return rule, fmt.Errorf("constraint %q: invalid length %q", name, arg)
+		}
+		rule.check = func(v reflect.Value) string {
+			l := lengthOf(v)
+			switch {
+			case name == "minLen" && l < n:
+				return fmt.Sprintf("length must be at least %d, got %d", n, l)
+			case name == "maxLen" && l > n:
+				return fmt.Sprintf("length must be at most %d, got %d", n, l)
+			case name == "len" && l != n:
+				return fmt.Sprintf("length must be %d, got %d", n, l)
+			}
+			return ""
+		}
+		rule.schema = func(s *jsonSchema) {
+			lo, hi := &s.MinLength, &s.MaxLength
+			switch {
+			case isType(s, "array"):
+				lo, hi = &s.MinItems, &s.MaxItems
+			case isType(s, "object"):
+				lo, hi = &s.MinProperties, &s.MaxProperties
+			}
+			if name != "maxLen" {
+				*lo = &n
+			}
+			if name != "minLen" {
+				*hi = &n
+			}
+		}
+
+	case "enum":
+		kind := valueType.Kind()
+		if kind != reflect.String && !isNumber(kind) {
+			return rule, fmt.Errorf("constraint %q needs a string or number field, got %v", name, f.typ)
+		}
+		options := strings.Split(arg, "|")
+		values := make([]interface{}, len(options))
+		for i, option := range options {
+			if kind == reflect.String {
+				values[i] = option
+				continue
+			}
+			n, err := strconv.ParseFloat(option, 64)
+			if err != nil {
+				return rule, fmt.Errorf("constraint %q: invalid number %q", name, option)
+			}
+			values[i] = n
+		}
+		rule.check = func(v reflect.Value) string {
+			for _, value := range values {
+				if (kind == reflect.String && v.String() == value) || (kind != reflect.String && numberOf(v) == value) {
+					return ""
+				}
+			}
+			// The value is left out, as the message is logged above debug level
+			return "must be one of " + strings.Join(options, ", ")
+		}
+		rule.schema = func(s *jsonSchema) { s.Enum = values }
+
+	case "pattern":
+		if valueType.Kind() != reflect.String {
+			return rule, fmt.Errorf("constraint %q needs a string field, got %v", name, f.typ)
+		}
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return rule, fmt.Errorf("constraint %q: %v", name, err)
+		}
+		rule.check = func(v reflect.Value) string {
+			if !re.MatchString(v.String()) {
+				return "must match pattern " + arg
+			}
+			return ""
+		}
+		rule.schema = func(s *jsonSchema) { s.Pattern = arg }
+
+	default:
+		return rule, fmt.Errorf("unknown constraint %q", name)
+	}
+	return rule, nil
+}
+
+// isRequired reports whether a field must be present in the JSON object and
+// not null. The railguard tag options required and optional decide; without
+// them, fields that are not pointers and do not have omitempty are required
+// if implicit is true.
+func isRequired(f schemaField, fc fieldConstraints, implicit bool) bool {
+	switch {
+	case fc.required:
+		return true
+	case fc.optional:
+		return false
+	}
+	return implicit && !f.omitEmpty && f.typ.Kind() != reflect.Ptr
+}
+
+// indirect returns the type pointed to by t, following all pointers.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isLengthRule reports whether the named rule constrains a length.
+func isLengthRule(name string) bool {
+	return name == "minLen" || name == "maxLen" || name == "len"
+}
+
+// hasLen reports whether values of kind k have a length.
+func hasLen(k reflect.Kind) bool {
+	return k == reflect.String || k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
+
+// isNumber reports whether kind k is a number.
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numberOf returns the value of a number as a float64.
+func numberOf(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	case v.CanFloat():
+		return v.Float()
+	}
+	return math.NaN()
+}
+
+// lengthOf returns the length of v, counting runes for strings.
+func lengthOf(v reflect.Value) int {
+	if v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String())
+	}
+	return v.Len()
+}
+
+// formatNumber formats a number for a violation message.
+func formatNumber(v reflect.Value) string {
+	return strconv.FormatFloat(numberOf(v), 'g', -1, 64)
+}
+
+// isType reports whether the JSON Schema s has the given type, including
+// nullable types.
+func isType(s *jsonSchema, typ string) bool {
+	switch t := s.Type.(type) {
+	case string:
+		return t == typ
+	case []string:
+		return len(t) > 0 && t[0] == typ
+	}
+	return false
+}
+
+// maxPtr returns a pointer to the larger of *p and n, treating nil as unset.
+func maxPtr(p *int, n int) *int {
+	if p != nil && *p > n {
+		return p
+	}
+	return &n
+}
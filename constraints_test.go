@@ -0,0 +1,359 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+type constrainedLine struct {
+	SKU    string  `json:"sku" railguard:"pattern=^[A-Z]{3}-\\d{1,4}$"`
+	Amount float64 `json:"amount" railguard:"required,min=0"`
+}
+
+type constrainedInvoice struct {
+	Status  string            `json:"status" railguard:"required,enum=pending|paid|void"`
+	Score   int               `json:"score,omitempty" railguard:"min=0,max=100"`
+	Code    *string           `json:"code,omitempty" railguard:"minLen=2,maxLen=4"`
+	Title   string            `json:"title" railguard:"nonempty"`
+	Tags    []string          `json:"tags,omitempty" railguard:"enum=urgent|late"`
+	Pair    []int             `json:"pair,omitempty" railguard:"len=2"`
+	Lines   []constrainedLine `json:"lines,omitempty"`
+	Extra   map[string]string `json:"extra,omitempty" railguard:"maxLen=1"`
+	Comment string            `json:"comment,omitempty"`
+}
+
+func TestSchemaConstraints(t *testing.T) {
+	schema, err := railguard.NewSchema(&constrainedInvoice{})
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	t.Run("valid output", func(t *testing.T) {
+		parsed, err := schema.Unmarshal([]byte(`{
+			"status": "paid", "score": 100, "code": "AB", "title": "March",
+			"tags": ["late"], "pair": [1, 2],
+			"lines": [{"sku": "ABC-12", "amount": 0}],
+			"extra": {"a": "b"}
+		}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if parsed.(*constrainedInvoice).Status != "paid" {
+			t.Errorf("unexpected parsed value: %+v", parsed)
+		}
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		_, err := schema.Unmarshal([]byte(`{
+			"status": "maybe", "score": -40, "code": "ABCDE", "title": "",
+			"tags": ["late", "never"], "pair": [1],
+			"lines": [{"sku": "ABC-1", "amount": 1}, {"sku": "abc", "amount": -2}, {"sku": "XYZ-1"}],
+			"extra": {"a": "b", "c": "d"}
+		}`))
+
+		var constraintErr *railguard.ConstraintError
+		if !errors.As(err, &constraintErr) {
+			t.Fatalf("expected ConstraintError, got %v", err)
+		}
+
+		want := []railguard.Violation{
+			{Path: "status", Constraint: "enum", Message: `must be one of pending, paid, void`},
+			{Path: "score", Constraint: "min", Message: "must be at least 0, got -40"},
+			{Path: "code", Constraint: "maxLen", Message: "length must be at most 4, got 5"},
+			{Path: "title", Constraint: "nonempty", Message: "must not be empty"},
+			{Path: "tags[1]", Constraint: "enum", Message: `must be one of urgent, late`},
+			{Path: "pair", Constraint: "len", Message: "length must be 2, got 1"},
+			{Path: "lines[1].sku", Constraint: "pattern", Message: `must match pattern ^[A-Z]{3}-\d{1,4}$`},
+			{Path: "lines[1].amount", Constraint: "min", Message: "must be at least 0, got -2"},
+			{Path: "lines[2].amount", Constraint: "required", Message: "is required"},
+			{Path: "extra", Constraint: "maxLen", Message: "length must be at most 1, got 2"},
+		}
+		if !reflect.DeepEqual(constraintErr.Violations, want) {
+			t.Errorf("unexpected violations:\ngot:  %+v\nwant: %+v", constraintErr.Violations, want)
+		}
+		if !strings.Contains(constraintErr.Error(), "lines[2].amount: is required") {
+			t.Errorf("expected error message to list paths, got %q", constraintErr.Error())
+		}
+	})
+
+	t.Run("missing and null required fields", func(t *testing.T) {
+		for _, input := range []string{`{"title": "x"}`, `{"status": null, "title": "x"}`} {
+			_, err := schema.Unmarshal([]byte(input))
+			var constraintErr *railguard.ConstraintError
+			if !errors.As(err, &constraintErr) || len(constraintErr.Violations) != 1 || constraintErr.Violations[0].Path != "status" {
+				t.Errorf("%s: expected required violation for status, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("absent optional fields are not checked", func(t *testing.T) {
+		// score is 0 and code is nil, both within their constraints or skipped
+		if _, err := schema.Unmarshal([]byte(`{"status": "void", "title": "x"}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unmarshal into", func(t *testing.T) {
+		var dest constrainedInvoice
+		err := schema.UnmarshalInto([]byte(`{"status": "void", "title": "x", "score": 101}`), &dest)
+		var constraintErr *railguard.ConstraintError
+		if !errors.As(err, &constraintErr) || constraintErr.Violations[0].Path != "score" {
+			t.Errorf("expected max violation, got %v", err)
+		}
+	})
+}
+
+func TestSchemaConstraintTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"unknown constraint", &struct {
+			A string `railguard:"bogus=1"`
+		}{}},
+		{"missing value", &struct {
+			A int `railguard:"min"`
+		}{}},
+		{"min on string", &struct {
+			A string `railguard:"min=1"`
+		}{}},
+		{"invalid number", &struct {
+			A int `railguard:"max=ten"`
+		}{}},
+		{"length of number", &struct {
+			A int `railguard:"len=2"`
+		}{}},
+		{"invalid pattern", &struct {
+			A string `railguard:"pattern=("`
+		}{}},
+		{"invalid enum number", &struct {
+			A int `railguard:"enum=1|two"`
+		}{}},
+		{"nested struct", &struct {
+			Inner []struct {
+				B bool `railguard:"enum=true"`
+			}
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := railguard.NewSchema(tt.v); !errors.Is(err, railguard.ErrInvalidConstraint) {
+				t.Errorf("expected ErrInvalidConstraint, got %v", err)
+			}
+		})
+	}
+
+	t.Run("pattern with commas", func(t *testing.T) {
+		type Code struct {
+			Value string `json:"value" railguard:"required,pattern=^[a-z]{2,3}$"`
+		}
+		schema, err := railguard.NewSchema(&Code{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := schema.Unmarshal([]byte(`{"value": "abcd"}`)); err == nil {
+			t.Error("expected pattern violation")
+		}
+		if _, err := schema.Unmarshal([]byte(`{"value": "abc"}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("pattern after a space", func(t *testing.T) {
+		type Code struct {
+			Value string `json:"value" railguard:"required, pattern=^a,b$"`
+		}
+		schema, err := railguard.NewSchema(&Code{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := schema.Unmarshal([]byte(`{"value": "a"}`)); err == nil {
+			t.Error("expected pattern violation")
+		}
+		if _, err := schema.Unmarshal([]byte(`{"value": "a,b"}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSchemaConstraintsJSONSchema(t *testing.T) {
+	type Item struct {
+		Status string   `json:"status" railguard:"enum=open|closed"`
+		Score  *int     `json:"score" railguard:"required,min=0,max=100"`
+		Name   string   `json:"name,omitempty" railguard:"nonempty,maxLen=10"`
+		Tags   []string `json:"tags" railguard:"len=2,pattern=^#"`
+		Level  int      `json:"level,string" railguard:"min=1"`
+	}
+
+	schema, _ := railguard.NewSchema(&Item{})
+	assertJSONSchema(t, schema, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"status": {"type": "string", "enum": ["open", "closed"]},
+			"score": {"type": "integer", "minimum": 0, "maximum": 100},
+			"name": {"type": "string", "minLength": 1, "maxLength": 10},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^#"}, "minItems": 2, "maxItems": 2},
+			"level": {"type": "string"}
+		},
+		"required": ["status", "score", "tags", "level"],
+		"additionalProperties": false
+	}`)
+}
+
+func TestGuardConstraints(t *testing.T) {
+	type Rating struct {
+		Score int `json:"score" railguard:"min=0,max=100"`
+	}
+
+	outputs := []string{`{"score": 140}`, `{"score": 90}`}
+	calls := 0
+	client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		output := outputs[calls]
+		calls++
+		return output, nil
+	})
+
+	g, err := railguard.New(
+		railguard.WithClient(client),
+		railguard.WithSchema(&Rating{}),
+		railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	result, err := g.Run(context.Background(), "rate it")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Parsed.(*Rating).Score != 90 || result.Metadata.Attempts != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var schemaErr *railguard.SchemaError
+	var constraintErr *railguard.ConstraintError
+	if first := result.Metadata.Trace[0].Err; !errors.As(first, &schemaErr) || !errors.As(first, &constraintErr) {
+		t.Errorf("expected SchemaError wrapping ConstraintError, got %v", first)
+	}
+}
+
+func TestSchemaRequiredFields(t *testing.T) {
+	type Line struct {
+		SKU    string   `json:"sku"`
+		Amount float64  `json:"amount"`
+		Note   *string  `json:"note"`
+		Tags   []string `json:"tags,omitempty"`
+	}
+	type Invoice struct {
+		ID       string `json:"id"`
+		Customer struct {
+			Name  string `json:"name"`
+			Email string `json:"email" railguard:"optional"`
+		} `json:"customer"`
+		Invoices []Line  `json:"invoices"`
+		Memo     *string `json:"memo" railguard:"required"`
+	}
+
+	violations := func(t *testing.T, err error) []string {
+		t.Helper()
+		var constraintErr *railguard.ConstraintError
+		if !errors.As(err, &constraintErr) {
+			t.Fatalf("expected ConstraintError, got %v", err)
+		}
+		paths := make([]string, len(constraintErr.Violations))
+		for i, v := range constraintErr.Violations {
+			if v.Constraint != "required" {
+				t.Errorf("unexpected constraint %q", v.Constraint)
+			}
+			paths[i] = v.Path
+		}
+		return paths
+	}
+
+	t.Run("empty object", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&Invoice{})
+		_, err := schema.Unmarshal([]byte(`{}`))
+		want := []string{"id", "customer", "invoices", "memo"}
+		if got := violations(t, err); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected missing %v, got %v", want, got)
+		}
+	})
+
+	t.Run("nested structs and slices", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&Invoice{})
+		_, err := schema.Unmarshal([]byte(`{
+			"id": "INV-1",
+			"customer": {},
+			"invoices": [
+				{"sku": "A", "amount": 1},
+				{"sku": "B", "amount": 2, "note": null},
+				{"sku": "C", "amount": null}
+			],
+			"memo": null
+		}`))
+		want := []string{"customer.name", "invoices[2].amount", "memo"}
+		if got := violations(t, err); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected missing %v, got %v", want, got)
+		}
+	})
+
+	t.Run("complete object", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&Invoice{})
+		_, err := schema.Unmarshal([]byte(`{"id": "", "customer": {"name": ""}, "invoices": [], "memo": "x"}`))
+		if err != nil {
+			t.Errorf("zero values that are present should pass, got %v", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&Invoice{})
+		schema.WithRequiredFields(false)
+		if schema.RequiresFields() {
+			t.Error("expected RequiresFields to be false")
+		}
+
+		// Explicitly required fields are still checked
+		_, err := schema.Unmarshal([]byte(`{}`))
+		if got := violations(t, err); !reflect.DeepEqual(got, []string{"memo"}) {
+			t.Errorf("expected only memo to be missing, got %v", got)
+		}
+		if _, err := schema.Unmarshal([]byte(`{"memo": "x"}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("required and optional are exclusive", func(t *testing.T) {
+		_, err := railguard.NewSchema(&struct {
+			A string `railguard:"required,optional"`
+		}{})
+		if !errors.Is(err, railguard.ErrInvalidConstraint) {
+			t.Errorf("expected ErrInvalidConstraint, got %v", err)
+		}
+	})
+
+	t.Run("json schema", func(t *testing.T) {
+		type Item struct {
+			A string  `json:"a"`
+			B string  `json:"b" railguard:"optional"`
+			C *string `json:"c" railguard:"required"`
+			D int     `json:"d,omitempty"`
+		}
+		schema, _ := railguard.NewSchema(&Item{})
+		doc, _ := schema.JSONSchema()
+		if !strings.Contains(string(doc), `"required":["a","c"]`) {
+			t.Errorf("unexpected required list: %s", doc)
+		}
+
+		doc, _ = schema.WithRequiredFields(false).JSONSchema()
+		if !strings.Contains(string(doc), `"required":["c"]`) {
+			t.Errorf("unexpected required list without implicit required fields: %s", doc)
+		}
+	})
+}
@@ -1,6 +1,11 @@
 package railguard
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Detector checks prompts before they are sent to the LLM.
 // Detectors are used for safety checks like prompt injection detection,
@@ -45,3 +50,95 @@ func (d Detectors) Detect(ctx context.Context, prompt string) error {
 	return nil
 }
 
+
+// TimeoutPolicy decides how a detector that exceeds its timeout is treated.
+type TimeoutPolicy int
+
+const (
+	// FailClosed rejects the prompt when a detector times out.
+	// The detector's DetectionError wraps ErrDetectorTimeout.
+	FailClosed TimeoutPolicy = iota
+
+	// FailOpen lets the prompt through when a detector times out.
+	FailOpen
+)
+
+// String returns the policy's name.
+func (p TimeoutPolicy) String() string {
+	switch p {
+	case FailClosed:
+		return "fail-closed"
+	case FailOpen:
+		return "fail-open"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectorWithTimeout wraps a detector so that a single Detect call may take
+// at most timeout. When the timeout expires, the policy decides whether the
+// prompt is rejected (FailClosed) or allowed (FailOpen). Cancellation of the
+// caller's context is always reported as an error, regardless of the policy.
+//
+// The wrapped detector keeps its name. A detector that ignores context
+// cancellation keeps running in the background after the timeout.
+func DetectorWithTimeout(detector Detector, timeout time.Duration, policy TimeoutPolicy) Detector {
+	return &timeoutDetector{
+		detector: detector,
+		timeout:  timeout,
+		policy:   policy,
+	}
+}
+
+// timeoutDetector enforces a per-call timeout on a detector.
+type timeoutDetector struct {
+	detector Detector
+	timeout  time.Duration
+	policy   TimeoutPolicy
+}
+
+// Detect runs the wrapped detector with the configured timeout.
+func (d *timeoutDetector) Detect(ctx context.Context, prompt string) error {
+	return detectWithTimeout(ctx, d.detector, prompt, d.timeout, d.policy)
+}
+
+// Name returns the wrapped detector's name.
+func (d *timeoutDetector) Name() string {
+	return d.detector.Name()
+}
+
+// detectWithTimeout runs a detector, giving up after timeout.
+// A timeout of 0 or less runs the detector without a timeout.
+func detectWithTimeout(ctx context.Context, detector Detector, prompt string, timeout time.Duration, policy TimeoutPolicy) error {
+	if timeout <= 0 {
+		return detector.Detect(ctx, prompt)
+	}
+
+	detectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Run in a goroutine so a detector that ignores its context cannot
+	// exceed the timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- detector.Detect(detectCtx, prompt)
+	}()
+
+	select {
+	case err := <-done:
+		// A detector that respects its context reports the timeout itself
+		if err == nil || ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	case <-detectCtx.Done():
+		// The caller's cancellation always wins over the timeout policy
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if policy == FailOpen {
+		return nil
+	}
+	return fmt.Errorf("%w after %v", ErrDetectorTimeout, timeout)
+}
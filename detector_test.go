@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/RasmusHilmar1/railguard"
 )
@@ -85,3 +86,73 @@ func TestDetectors(t *testing.T) {
 	})
 }
 
+
+func TestDetectorWithTimeout(t *testing.T) {
+	slow := railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// stubborn ignores its context and never returns on its own
+	block := make(chan struct{})
+	defer close(block)
+	stubborn := railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+		<-block
+		return nil
+	})
+
+	t.Run("fail closed rejects on timeout", func(t *testing.T) {
+		d := railguard.DetectorWithTimeout(slow, 10*time.Millisecond, railguard.FailClosed)
+		if err := d.Detect(context.Background(), "test"); !errors.Is(err, railguard.ErrDetectorTimeout) {
+			t.Errorf("expected ErrDetectorTimeout, got %v", err)
+		}
+	})
+
+	t.Run("fail open allows on timeout", func(t *testing.T) {
+		d := railguard.DetectorWithTimeout(slow, 10*time.Millisecond, railguard.FailOpen)
+		if err := d.Detect(context.Background(), "test"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("enforced for detectors that ignore their context", func(t *testing.T) {
+		d := railguard.DetectorWithTimeout(stubborn, 10*time.Millisecond, railguard.FailClosed)
+
+		start := time.Now()
+		if err := d.Detect(context.Background(), "test"); !errors.Is(err, railguard.ErrDetectorTimeout) {
+			t.Errorf("expected ErrDetectorTimeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("timeout was not enforced, took %v", elapsed)
+		}
+	})
+
+	t.Run("caller cancellation is not swallowed by fail open", func(t *testing.T) {
+		d := railguard.DetectorWithTimeout(slow, time.Second, railguard.FailOpen)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := d.Detect(ctx, "test"); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("passes through results within the timeout", func(t *testing.T) {
+		expectedErr := errors.New("rejected")
+		inner := railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+			return expectedErr
+		})
+
+		d := railguard.DetectorWithTimeout(inner, time.Second, railguard.FailOpen)
+		if err := d.Detect(context.Background(), "test"); err != expectedErr {
+			t.Errorf("expected %v, got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("keeps the wrapped name", func(t *testing.T) {
+		d := railguard.DetectorWithTimeout(&mockDetector{name: "intent"}, time.Second, railguard.FailOpen)
+		if d.Name() != "intent" {
+			t.Errorf("expected name 'intent', got %q", d.Name())
+		}
+	})
+}
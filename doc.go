@@ -10,8 +10,9 @@ Railguard implements a pipeline-based approach to LLM safety:
 
  1. Detection - Pre-generation safety checks (e.g., prompt injection detection)
  2. Generation - Calling the LLM client
- 3. Validation - Post-generation output validation (e.g., JSON syntax)
- 4. Schema - Structured output parsing into Go types
+ 3. Transformation - Output rewriting (e.g., stripping code fences)
+ 4. Validation - Post-generation output validation (e.g., JSON syntax)
+ 5. Schema - Structured output parsing into Go types
 
 Detection failures are fatal and not retried. Generation and validation failures
 may be retried based on the retry configuration.
@@ -56,6 +57,32 @@ For simple use cases, use ClientFunc:
 	    return response, nil
 	})
 
+Conversations with system prompts and multiple turns can be run with
+Guard.RunMessages. Providers with a native chat API implement ChatClient:
+
+	type ChatClient interface {
+	    Chat(ctx context.Context, messages []Message) (string, error)
+	}
+
+Detectors only see user messages by default, so trusted system prompts are
+not scanned. Use WithDetectRoles to change this.
+
+The clients directory provides ready-made provider clients: clients/openai
+for OpenAI-compatible APIs, clients/anthropic for the Anthropic Messages API,
+and clients/ollama for local Ollama models. Their errors mark rate limits and
+server errors as temporary, so only those are retried. Clients report the
+provider's stop reason with SetStopReason; it is recorded in
+Result.Metadata.StopReason.
+
+Wrap a client with NewCircuitBreaker to fail fast with ErrCircuitOpen while a
+provider is down instead of retrying against it.
+Use NewFallbackClient to try a backup model when the primary fails; the
+answering client is reported in Result.Metadata.
+
+Clients, detectors, and validators can be wrapped with middlewares using
+WithClientMiddleware, WithDetectorMiddleware, and WithValidatorMiddleware.
+The middleware package provides timing, logging, and concurrency limits.
+
 # Built-in Detectors
 
 The detectors package provides pre-built detectors:
@@ -63,6 +90,15 @@ The detectors package provides pre-built detectors:
   - Keywords - Detects prompt injection keywords
   - Role - Detects role manipulation attempts
 
+# Built-in Transformers
+
+The transformers package provides pre-built transformers that rewrite the
+output before validators and the schema see it:
+
+  - CodeFence - Strips markdown code fences
+  - XMLTag - Extracts the contents of an XML-style tag
+  - Trim - Strips a byte order mark and surrounding whitespace
+
 # Built-in Validators
 
 The validators package provides pre-built validators:
@@ -87,9 +123,43 @@ Schemas validate that LLM output matches your Go types:
 	    railguard.WithSchema(&Response{}),
 	)
 
+Use RunAs or NewTyped to receive the parsed output as a typed pointer:
+
+	result, err := railguard.RunAs[Response](ctx, guard, prompt)
+	fmt.Println(result.Parsed.Name)
+
 By default, schemas are strict and reject unknown fields to prevent
 hallucinated data from entering your application.
 
+Schemas also require fields by default: a field that is not a pointer and
+has no omitempty option must be present in the output, including fields of
+nested structs. Use `railguard:"optional"` to exempt a field, or
+WithRequiredFields(false) to only require fields tagged `railguard:"required"`.
+
+Constraints declared in railguard struct tags are checked after decoding,
+and violations are reported as a ConstraintError listing each failing field:
+
+	type Review struct {
+	    Status string `json:"status" railguard:"required,enum=pending|approved"`
+	    Score  int    `json:"score" railguard:"min=0,max=100"`
+	}
+
+A SchemaError lists every problem found in the output as a SchemaIssue
+with a kind (IssueUnknownField, IssueTypeMismatch, IssueMissingRequired,
+IssueConstraint, IssueSyntax, or IssueTrailingData), a JSON Pointer path,
+and the expected and actual JSON types. Syntax errors are located by byte
+offset instead.
+
+WithLenientSchema(true) repairs almost-JSON before parsing: trailing
+commas, single quotes, unquoted keys, comments, Python True/False/None, and
+objects truncated at the token limit. Repair is only attempted when the
+output is not valid JSON, and the fixes applied are recorded in
+Result.Metadata.JSONFixes. RepairJSON exposes the repair on its own.
+
+Schema.JSONSchema generates a JSON Schema (draft 2020-12) document from the
+Go type for providers' structured output APIs. The description and example
+struct tags document individual fields.
+
 # Retry Configuration
 
 Control retry behavior with RetryConfig:
@@ -107,15 +177,22 @@ Control retry behavior with RetryConfig:
 	    railguard.WithRetry(config),
 	)
 
+Client errors implementing Temporary() bool can mark themselves as permanent,
+and errors implementing RetryAfter() time.Duration override the backoff delay.
+Use WithRetryPolicy to decide retries and delays yourself.
+
 # Error Types
 
 Railguard provides typed errors for handling different failure modes:
 
   - DetectionError - A detector rejected the prompt
+  - TransformError - A transformer rejected the output
   - ValidationError - A validator rejected the output
   - SchemaError - The output didn't match the schema
   - GenerationError - The LLM client failed
-  - MaxRetriesError - Maximum retries exceeded
+  - MaxRetriesError - Maximum retries exceeded; its Trace records every attempt
+  - RetryAbortedError - Retrying stopped early (ErrInsufficientTime or ErrRetryBudgetExhausted)
+  - MultiError - Several detectors or validators failed (see WithCollectAll)
 
 Use errors.As to handle specific error types:
 
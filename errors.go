@@ -3,6 +3,8 @@ package railguard
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
 // Configuration errors - sentinel errors for common configuration mistakes.
@@ -13,6 +15,12 @@ var (
 	// ErrNoSchema is returned when schema validation is required but no schema was provided.
 	ErrNoSchema = errors.New("railguard: no schema provided")
 
+	// ErrNoMessages is returned when RunMessages is called with an empty conversation.
+	ErrNoMessages = errors.New("railguard: no messages provided")
+
+	// ErrNoDetectRoles is returned when WithDetectRoles is called without roles.
+	ErrNoDetectRoles = errors.New("railguard: at least one detect role is required")
+
 	// ErrNilClient is returned when a nil client is passed to WithClient.
 	ErrNilClient = errors.New("railguard: client cannot be nil")
 
@@ -22,14 +30,91 @@ var (
 	// ErrNilValidator is returned when a nil validator is passed to WithValidators.
 	ErrNilValidator = errors.New("railguard: validator cannot be nil")
 
+	// ErrNilTransformer is returned when a nil transformer is passed to WithTransformers.
+	ErrNilTransformer = errors.New("railguard: transformer cannot be nil")
+
+	// ErrNilObserver is returned when a nil observer is passed to WithObserver.
+	ErrNilObserver = errors.New("railguard: observer cannot be nil")
+
+	// ErrNilLogger is returned when a nil logger is passed to WithLogger.
+	ErrNilLogger = errors.New("railguard: logger cannot be nil")
+
+	// ErrNilMiddleware is returned when a nil middleware is passed to
+	// WithClientMiddleware, WithDetectorMiddleware, or WithValidatorMiddleware.
+	ErrNilMiddleware = errors.New("railguard: middleware cannot be nil")
+
+	// ErrChatMiddleware is returned by New when a client middleware returns a
+	// Client that does not implement ChatClient although the wrapped client
+	// does, since it would flatten the conversation into a single prompt.
+	ErrChatMiddleware = errors.New("railguard: client middleware does not support chat")
+
+	// ErrNilRetryPolicy is returned when a nil policy is passed to WithRetryPolicy.
+	ErrNilRetryPolicy = errors.New("railguard: retry policy cannot be nil")
+
+	// ErrNilRepairPrompter is returned when a nil prompter is passed to WithRepairPrompter.
+	ErrNilRepairPrompter = errors.New("railguard: repair prompter cannot be nil")
+
 	// ErrInvalidRetryConfig is returned when retry configuration is invalid.
 	ErrInvalidRetryConfig = errors.New("railguard: invalid retry configuration")
 
+	// ErrInvalidRetryBudget is returned when a retry budget is configured
+	// with fewer than one token or a negative refill.
+	ErrInvalidRetryBudget = errors.New("railguard: invalid retry budget")
+
+	// ErrNilRetryBudget is returned when a nil budget is passed to WithRetryBudget.
+	ErrNilRetryBudget = errors.New("railguard: retry budget cannot be nil")
+
+	// ErrInvalidCircuitBreakerConfig is returned when a circuit breaker
+	// configuration is invalid.
+	ErrInvalidCircuitBreakerConfig = errors.New("railguard: invalid circuit breaker configuration")
+
+	// ErrInvalidHedgeConfig is returned when a hedge configuration is invalid.
+	ErrInvalidHedgeConfig = errors.New("railguard: invalid hedge configuration")
+
+	// ErrInvalidClientName is returned by NewFallbackClient when a client name
+	// is empty or not unique.
+	ErrInvalidClientName = errors.New("railguard: invalid client name")
+
 	// ErrInvalidTimeout is returned when a non-positive timeout is provided.
 	ErrInvalidTimeout = errors.New("railguard: timeout must be positive")
 
 	// ErrInvalidSchema is returned when the schema is not a pointer to a struct.
 	ErrInvalidSchema = errors.New("railguard: schema must be a pointer to a struct")
+
+	// ErrInvalidConstraint is returned when a railguard struct tag of the
+	// schema type is malformed or does not fit the field's type.
+	ErrInvalidConstraint = errors.New("railguard: invalid constraint tag")
+
+	// ErrUnrepairableJSON is returned by RepairJSON when the input has defects
+	// it does not fix.
+	ErrUnrepairableJSON = errors.New("railguard: JSON cannot be repaired")
+
+	// ErrSchemaTypeMismatch is returned when a TypedGuard's type parameter does
+	// not match the guard's configured schema type.
+	ErrSchemaTypeMismatch = errors.New("railguard: schema type does not match type parameter")
+)
+
+// Runtime errors - wrapped by the errors returned when a run stops early.
+var (
+	// ErrDetectorTimeout is wrapped by the DetectionError of a detector that
+	// exceeded its timeout under the FailClosed policy.
+	ErrDetectorTimeout = errors.New("railguard: detector timed out")
+
+	// ErrAttemptTimeout is wrapped by the GenerationError of a generation call
+	// that exceeded the timeout set with WithAttemptTimeout.
+	ErrAttemptTimeout = errors.New("railguard: attempt timed out")
+
+	// ErrInsufficientTime is the reason of a RetryAbortedError returned when
+	// another attempt could not finish before the context deadline.
+	ErrInsufficientTime = errors.New("railguard: not enough time left for another attempt")
+
+	// ErrRetryBudgetExhausted is the reason of a RetryAbortedError returned when
+	// the RetryBudget set with WithRetryBudget has no tokens left.
+	ErrRetryBudgetExhausted = errors.New("railguard: retry budget exhausted")
+
+	// ErrCircuitOpen is returned by a CircuitBreaker that rejects a request
+	// while open. Guard.Run does not retry it.
+	ErrCircuitOpen = errors.New("railguard: circuit breaker is open")
 )
 
 // DetectionError wraps errors from detectors with context about which detector failed.
@@ -68,15 +153,46 @@ func (e *ValidationError) Unwrap() error {
 	return e.Err
 }
 
+// TransformError wraps errors from transformers with context about which transformer failed.
+type TransformError struct {
+	// Transformer is the name of the transformer that failed.
+	Transformer string
+	// Err is the underlying error from the transformer.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("transformation failed [%s]: %v", e.Transformer, e.Err)
+}
+
+// Unwrap returns the underlying error for errors.Is/As support.
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
 // SchemaError wraps errors from schema validation.
 type SchemaError struct {
-	// Err is the underlying JSON unmarshaling or validation error.
+	// Err is the underlying JSON unmarshaling or validation error, such as
+	// a *json.SyntaxError, a *json.UnmarshalTypeError, or a *ConstraintError.
 	Err error
+
+	// Issues lists every problem found in the output, in a machine-readable
+	// form. It is empty for errors not produced by Schema.Unmarshal.
+	Issues []SchemaIssue
 }
 
 // Error implements the error interface.
+// It lists every issue, or describes Err if there are none.
 func (e *SchemaError) Error() string {
-	return fmt.Sprintf("schema validation failed: %v", e.Err)
+	if len(e.Issues) == 0 {
+		return fmt.Sprintf("schema validation failed: %v", e.Err)
+	}
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		parts[i] = issue.String()
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
 }
 
 // Unwrap returns the underlying error for errors.Is/As support.
@@ -84,6 +200,72 @@ func (e *SchemaError) Unwrap() error {
 	return e.Err
 }
 
+// IssueKind classifies a SchemaIssue.
+type IssueKind string
+
+const (
+	// IssueSyntax means the output is not valid JSON.
+	IssueSyntax IssueKind = "syntax"
+
+	// IssueTrailingData means the JSON value is followed by more data.
+	IssueTrailingData IssueKind = "trailing_data"
+
+	// IssueUnknownField means an object has a key that matches no field.
+	// Only reported for strict schemas.
+	IssueUnknownField IssueKind = "unknown_field"
+
+	// IssueTypeMismatch means a value has the wrong JSON type, e.g. a string
+	// where a number is expected, or a number that does not fit the field.
+	IssueTypeMismatch IssueKind = "type_mismatch"
+
+	// IssueMissingRequired means a required field is missing or null.
+	IssueMissingRequired IssueKind = "missing_required"
+
+	// IssueConstraint means a value violates a railguard tag constraint.
+	IssueConstraint IssueKind = "constraint"
+)
+
+// SchemaIssue describes a single problem found in an output by a Schema.
+type SchemaIssue struct {
+	// Kind classifies the issue.
+	Kind IssueKind
+
+	// Path is a JSON Pointer (RFC 6901) to the offending value, e.g.
+	// "/invoices/2/amount". It is empty for the whole document, and for
+	// syntax errors and trailing data, which are located by Offset.
+	Path string
+
+	// Expected is the JSON type the schema expects at Path, e.g. "integer"
+	// or "object". It is empty if the issue is not about a type.
+	Expected string
+
+	// Actual is the JSON type found at Path, e.g. "string", or "null" for a
+	// required field set to null. It is empty if the value is missing.
+	Actual string
+
+	// Constraint is the broken constraint for IssueConstraint, e.g. "max".
+	Constraint string
+
+	// Offset is the byte offset in the output of a syntax error or of the
+	// trailing data.
+	Offset int64
+
+	// Message describes the issue, e.g. "expected integer, got string". It
+	// never quotes values or keys from the output; Path locates the issue.
+	Message string
+}
+
+// String returns the issue as "location: message".
+func (i SchemaIssue) String() string {
+	switch {
+	case i.Kind == IssueSyntax || i.Kind == IssueTrailingData:
+		return fmt.Sprintf("offset %d: %s", i.Offset, i.Message)
+	case i.Path == "":
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
 // GenerationError wraps errors from the LLM client.
 type GenerationError struct {
 	// Err is the underlying error from the client.
@@ -106,6 +288,8 @@ type MaxRetriesError struct {
 	Attempts int
 	// LastErr is the error from the final attempt.
 	LastErr error
+	// Trace records every attempt in order, with its output and error.
+	Trace []AttemptRecord
 }
 
 // Error implements the error interface.
@@ -113,8 +297,84 @@ func (e *MaxRetriesError) Error() string {
 	return fmt.Sprintf("max retries exceeded after %d attempts: %v", e.Attempts, e.LastErr)
 }
 
-// Unwrap returns the underlying error for errors.Is/As support.
-func (e *MaxRetriesError) Unwrap() error {
-	return e.LastErr
+// Unwrap returns the errors of all attempts for errors.Is/As support,
+// so that errors.As finds e.g. a SchemaError from any attempt. LastErr comes
+// first, followed by the earlier attempts from the most recent, so errors.As
+// prefers the final error as it did before traces were recorded.
+// Without a trace, only LastErr is returned.
+func (e *MaxRetriesError) Unwrap() []error {
+	return attemptErrors(e.Trace, e.LastErr)
 }
 
+// RetryAbortedError is returned when a retryable failure was not retried
+// because of a limit other than MaxAttempts: because another attempt could
+// not finish before the context deadline, or because the retry budget ran out.
+type RetryAbortedError struct {
+	// Reason is the sentinel error describing why retrying stopped,
+	// ErrInsufficientTime or ErrRetryBudgetExhausted.
+	Reason error
+	// Attempts is the number of attempts made.
+	Attempts int
+	// LastErr is the error from the final attempt.
+	LastErr error
+	// Trace records every attempt in order, with its output and error.
+	Trace []AttemptRecord
+}
+
+// Error implements the error interface.
+func (e *RetryAbortedError) Error() string {
+	return fmt.Sprintf("retry aborted after %d attempts: %v: %v", e.Attempts, e.Reason, e.LastErr)
+}
+
+// Unwrap returns the reason and the errors of all attempts for errors.Is/As
+// support, so that errors.Is(err, ErrInsufficientTime) reports why retrying stopped.
+func (e *RetryAbortedError) Unwrap() []error {
+	return append([]error{e.Reason}, attemptErrors(e.Trace, e.LastErr)...)
+}
+
+// attemptErrors returns lastErr followed by the other errors recorded in
+// trace, from the most recent attempt to the first.
+func attemptErrors(trace []AttemptRecord, lastErr error) []error {
+	var errs []error
+	if lastErr != nil {
+		errs = append(errs, lastErr)
+	}
+	for i := len(trace) - 1; i >= 0; i-- {
+		if err := trace[i].Err; err != nil && !sameError(err, lastErr) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// sameError reports whether a and b are the same error value, without
+// panicking on errors of uncomparable types.
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
+
+// MultiError aggregates the failures of several detectors or validators.
+// It is returned instead of the first failure when collect-all mode is enabled
+// with WithCollectAll. The errors are listed in the order the detectors or
+// validators were configured.
+type MultiError struct {
+	// Errors are the individual failures, e.g. *DetectionError or *ValidationError.
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual errors for errors.Is/As support.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
@@ -2,6 +2,7 @@ package railguard_test
 
 import (
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -65,6 +66,30 @@ func TestValidationError(t *testing.T) {
 	})
 }
 
+func TestTransformError(t *testing.T) {
+	innerErr := errors.New("missing tag")
+	err := &railguard.TransformError{
+		Transformer: "xmltag:answer",
+		Err:         innerErr,
+	}
+
+	t.Run("error message", func(t *testing.T) {
+		msg := err.Error()
+		if !strings.Contains(msg, "transformation failed") {
+			t.Errorf("expected 'transformation failed' in message, got %q", msg)
+		}
+		if !strings.Contains(msg, "xmltag:answer") {
+			t.Errorf("expected transformer name in message, got %q", msg)
+		}
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		if unwrapped := err.Unwrap(); unwrapped != innerErr {
+			t.Errorf("expected unwrapped error to be %v, got %v", innerErr, unwrapped)
+		}
+	})
+}
+
 func TestSchemaError(t *testing.T) {
 	innerErr := errors.New("missing field")
 	err := &railguard.SchemaError{
@@ -85,6 +110,34 @@ func TestSchemaError(t *testing.T) {
 	})
 }
 
+func TestSchemaIssue(t *testing.T) {
+	tests := []struct {
+		issue railguard.SchemaIssue
+		want  string
+	}{
+		{railguard.SchemaIssue{Kind: railguard.IssueSyntax, Offset: 12, Message: "unexpected end of JSON input"}, "offset 12: unexpected end of JSON input"},
+		{railguard.SchemaIssue{Kind: railguard.IssueTypeMismatch, Message: "expected object, got array"}, "expected object, got array"},
+		{railguard.SchemaIssue{Kind: railguard.IssueConstraint, Path: "/lines/0/amount", Message: "must be at least 0, got -1"}, "/lines/0/amount: must be at least 0, got -1"},
+	}
+	for _, tt := range tests {
+		if got := tt.issue.String(); got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+	}
+
+	err := &railguard.SchemaError{
+		Err: errors.New("unknown field"),
+		Issues: []railguard.SchemaIssue{
+			{Kind: railguard.IssueUnknownField, Path: "/foo", Message: "unknown field"},
+			{Kind: railguard.IssueMissingRequired, Path: "/id", Message: "is required"},
+		},
+	}
+	want := "schema validation failed: /foo: unknown field; /id: is required"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
+
 func TestGenerationError(t *testing.T) {
 	innerErr := errors.New("network timeout")
 	err := &railguard.GenerationError{
@@ -123,8 +176,126 @@ func TestMaxRetriesError(t *testing.T) {
 	})
 
 	t.Run("unwrap", func(t *testing.T) {
-		if unwrapped := err.Unwrap(); unwrapped != innerErr {
-			t.Errorf("expected unwrapped error to be %v, got %v", innerErr, unwrapped)
+		unwrapped := err.Unwrap()
+		if len(unwrapped) != 1 || unwrapped[0] != innerErr {
+			t.Errorf("expected unwrapped errors to be [%v], got %v", innerErr, unwrapped)
+		}
+	})
+
+	t.Run("unwrap trace", func(t *testing.T) {
+		schemaErr := &railguard.SchemaError{Err: errors.New("missing field")}
+		validationErr := &railguard.ValidationError{Validator: "json", Err: errors.New("invalid")}
+		err := &railguard.MaxRetriesError{
+			Attempts: 2,
+			LastErr:  validationErr,
+			Trace: []railguard.AttemptRecord{
+				{Attempt: 1, Err: schemaErr, Stage: railguard.StageSchema},
+				{Attempt: 2, Err: validationErr, Stage: railguard.StageValidation},
+			},
+		}
+
+		var se *railguard.SchemaError
+		if !errors.As(err, &se) || se != schemaErr {
+			t.Errorf("expected errors.As to find SchemaError from first attempt")
+		}
+		if !errors.Is(err, validationErr) {
+			t.Errorf("expected errors.Is to find last attempt error")
+		}
+	})
+
+	t.Run("unwrap order", func(t *testing.T) {
+		first := &railguard.SchemaError{Err: errors.New("first")}
+		second := &railguard.SchemaError{Err: errors.New("second")}
+		third := &railguard.GenerationError{Err: errors.New("third")}
+		last := &railguard.SchemaError{Err: errors.New("last")}
+		err := &railguard.MaxRetriesError{
+			Attempts: 4,
+			LastErr:  last,
+			Trace: []railguard.AttemptRecord{
+				{Attempt: 1, Err: first},
+				{Attempt: 2, Err: second},
+				{Attempt: 3, Err: third},
+				{Attempt: 4, Err: last},
+			},
+		}
+
+		// LastErr first, then the earlier attempts from the most recent
+		want := []error{last, third, second, first}
+		if got := err.Unwrap(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+
+		var se *railguard.SchemaError
+		if !errors.As(err, &se) || se != last {
+			t.Errorf("expected errors.As to find LastErr, got %v", se)
+		}
+	})
+}
+
+func TestRetryAbortedError(t *testing.T) {
+	lastErr := &railguard.GenerationError{Err: errors.New("overloaded")}
+	err := &railguard.RetryAbortedError{
+		Reason:   railguard.ErrInsufficientTime,
+		Attempts: 2,
+		LastErr:  lastErr,
+		Trace: []railguard.AttemptRecord{
+			{Attempt: 1, Err: &railguard.SchemaError{Err: errors.New("missing field")}},
+			{Attempt: 2, Err: lastErr},
+		},
+	}
+
+	t.Run("error message", func(t *testing.T) {
+		msg := err.Error()
+		if !strings.Contains(msg, "retry aborted after 2 attempts") {
+			t.Errorf("expected attempt count in message, got %q", msg)
+		}
+		if !strings.Contains(msg, "not enough time") {
+			t.Errorf("expected reason in message, got %q", msg)
+		}
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		if !errors.Is(err, railguard.ErrInsufficientTime) {
+			t.Error("expected errors.Is to find the reason")
+		}
+		if !errors.Is(err, lastErr) {
+			t.Error("expected errors.Is to find the last error")
+		}
+		var schemaErr *railguard.SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Error("expected errors.As to find SchemaError from the first attempt")
+		}
+	})
+}
+
+func TestMultiError(t *testing.T) {
+	first := &railguard.DetectionError{Detector: "keywords", Err: errors.New("keyword found")}
+	second := &railguard.DetectionError{Detector: "role", Err: errors.New("role manipulation")}
+	err := &railguard.MultiError{Errors: []error{first, second}}
+
+	t.Run("error message lists failures in order", func(t *testing.T) {
+		msg := err.Error()
+		if !strings.Contains(msg, "2 errors") {
+			t.Errorf("expected error count in message, got %q", msg)
+		}
+		if strings.Index(msg, "keywords") > strings.Index(msg, "role") {
+			t.Errorf("expected failures in order, got %q", msg)
+		}
+	})
+
+	t.Run("errors.Is finds every error", func(t *testing.T) {
+		if !errors.Is(err, first) || !errors.Is(err, second) {
+			t.Error("errors.Is should find all inner errors")
+		}
+	})
+
+	t.Run("errors.As finds first matching error", func(t *testing.T) {
+		var detErr *railguard.DetectionError
+		if !errors.As(err, &detErr) {
+			t.Fatal("errors.As should find a DetectionError")
+		}
+		if detErr.Detector != "keywords" {
+			t.Errorf("expected first detector, got %q", detErr.Detector)
 		}
 	})
 }
@@ -133,12 +304,30 @@ func TestSentinelErrors(t *testing.T) {
 	sentinels := []error{
 		railguard.ErrNoClient,
 		railguard.ErrNoSchema,
+		railguard.ErrNoMessages,
+		railguard.ErrNoDetectRoles,
 		railguard.ErrNilClient,
 		railguard.ErrNilDetector,
 		railguard.ErrNilValidator,
+		railguard.ErrNilTransformer,
+		railguard.ErrNilRepairPrompter,
+		railguard.ErrNilObserver,
+		railguard.ErrNilLogger,
+		railguard.ErrNilRetryPolicy,
+		railguard.ErrNilRetryBudget,
+		railguard.ErrInvalidRetryBudget,
+		railguard.ErrInvalidCircuitBreakerConfig,
+		railguard.ErrCircuitOpen,
+		railguard.ErrInvalidHedgeConfig,
+		railguard.ErrNilMiddleware,
+		railguard.ErrChatMiddleware,
 		railguard.ErrInvalidRetryConfig,
 		railguard.ErrInvalidTimeout,
+		railguard.ErrInvalidClientName,
 		railguard.ErrInvalidSchema,
+		railguard.ErrInvalidConstraint,
+		railguard.ErrUnrepairableJSON,
+		railguard.ErrSchemaTypeMismatch,
 	}
 
 	for _, sentinel := range sentinels {
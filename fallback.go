@@ -0,0 +1,192 @@
+package railguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NamedClient is a Client with a name, reported in Result.Metadata when it
+// answers through a FallbackClient.
+type NamedClient struct {
+	// Name identifies the client, e.g. "gpt-4o" or "backup".
+	Name string
+
+	// Client is the client to call.
+	Client Client
+}
+
+// FallbackClient is a Client that tries an ordered list of named clients.
+// When a client fails with an error, the next one is tried within the same
+// attempt. Under WithAttemptTimeout, each client gets the full timeout, so a
+// client that hangs falls back to the next one. With WithRejectionFallback, the Guard also moves on to the next
+// client once a client's outputs were rejected by transformers, validators,
+// or the schema a given number of times.
+//
+// When used by a Guard, the name of the client that produced the final output
+// and the number of calls made to each client are recorded in
+// Result.Metadata. FallbackClient also implements ChatClient, so wrapped chat
+// clients keep their native message support. It is safe for concurrent use.
+type FallbackClient struct {
+	clients           []NamedClient
+	chats             []ChatClient
+	rejectionAttempts int
+}
+
+// NewFallbackClient creates a FallbackClient trying the clients in order.
+// Returns ErrNoClient if no clients are given, ErrNilClient if any client is
+// nil, or ErrInvalidClientName if a name is empty or used twice, since the
+// names identify the clients in Result.Metadata.
+func NewFallbackClient(clients ...NamedClient) (*FallbackClient, error) {
+	if len(clients) == 0 {
+		return nil, ErrNoClient
+	}
+
+	chats := make([]ChatClient, len(clients))
+	names := make(map[string]bool, len(clients))
+	for i, c := range clients {
+		if c.Client == nil {
+			return nil, ErrNilClient
+		}
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("%w: client %d has no name", ErrInvalidClientName, i)
+		case names[c.Name]:
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidClientName, c.Name)
+		}
+		names[c.Name] = true
+		chats[i] = AsChatClient(c.Client)
+	}
+
+	return &FallbackClient{
+		clients: append([]NamedClient(nil), clients...),
+		chats:   chats,
+	}, nil
+}
+
+// WithRejectionFallback returns a copy of the client that makes the Guard
+// switch to the next client once the current client produced the given
+// number of rejected outputs in a run. The last client is used for all
+// remaining attempts. By default, only generation errors cause a fallback.
+// The receiver is not modified, so it stays safe to share between goroutines.
+func (f *FallbackClient) WithRejectionFallback(attempts int) *FallbackClient {
+	c := *f
+	c.rejectionAttempts = attempts
+	return &c
+}
+
+// Generate implements the Client interface.
+func (f *FallbackClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return f.call(ctx, func(ctx context.Context, i int) (string, error) {
+		return f.clients[i].Client.Generate(ctx, prompt)
+	})
+}
+
+// Chat implements the ChatClient interface.
+func (f *FallbackClient) Chat(ctx context.Context, messages []Message) (string, error) {
+	return f.call(ctx, func(ctx context.Context, i int) (string, error) {
+		return f.chats[i].Chat(ctx, messages)
+	})
+}
+
+// Clients returns a copy of the clients in fallback order.
+func (f *FallbackClient) Clients() []NamedClient {
+	result := make([]NamedClient, len(f.clients))
+	copy(result, f.clients)
+	return result
+}
+
+// call tries the clients in order, starting with the first client that has
+// not used up its rejected outputs in the current run. Under a per-attempt
+// timeout of the Guard, each client gets the full timeout, so that a client
+// that hangs does not use up the time of the next one.
+func (f *FallbackClient) call(ctx context.Context, generate func(ctx context.Context, i int) (string, error)) (string, error) {
+	state := runStateFrom(ctx)
+	timeout, hasTimeout := ctx.Value(callTimeoutKey{}).(callTimeout)
+	parent := ctx
+	if hasTimeout {
+		parent = timeout.parent
+	}
+
+	var errs []error
+	for i := f.first(state); i < len(f.clients); i++ {
+		name := f.clients[i].Name
+		if state != nil {
+			state.recordCall(name)
+		}
+
+		clientCtx, cancel := ctx, context.CancelFunc(func() {})
+		if hasTimeout {
+			clientCtx, cancel = clientContext(ctx, timeout)
+		}
+		output, err := generate(clientCtx, i)
+		timedOut := errors.Is(clientCtx.Err(), context.DeadlineExceeded)
+		cancel()
+		if err == nil {
+			if state != nil {
+				state.recordOutput(name)
+			}
+			return output, nil
+		}
+
+		// The caller gave up; other clients would fail the same way
+		if parent.Err() != nil {
+			return "", err
+		}
+		if hasTimeout && timedOut {
+			err = fmt.Errorf("%w after %v", ErrAttemptTimeout, timeout.timeout)
+		}
+		errs = append(errs, fmt.Errorf("client %s: %w", name, err))
+	}
+	return "", &FallbackError{Errors: errs}
+}
+
+// clientContext returns the context of one client call under a per-attempt
+// timeout. It keeps the values of ctx but gets a deadline of its own, and is
+// canceled when the run context is.
+func clientContext(ctx context.Context, timeout callTimeout) (context.Context, context.CancelFunc) {
+	clientCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout.timeout)
+	stop := context.AfterFunc(timeout.parent, cancel)
+	return clientCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// FallbackError is returned by FallbackClient when every client it tried
+// failed. It unwraps to the error of each client, so errors.Is and errors.As
+// see the primary's failure as well as the backups'. DefaultRetryPolicy
+// retries it if any of the client errors is worth retrying.
+type FallbackError struct {
+	// Errors holds the error of each client tried, in fallback order,
+	// prefixed with the client's name.
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *FallbackError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = err.Error()
+	}
+	return "all clients failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the client errors for errors.Is/As support.
+func (e *FallbackError) Unwrap() []error {
+	return e.Errors
+}
+
+// first returns the index of the client to try first.
+func (f *FallbackClient) first(state *runState) int {
+	if f.rejectionAttempts <= 0 || state == nil {
+		return 0
+	}
+	for i, c := range f.clients[:len(f.clients)-1] {
+		if state.rejectionsOf(c.Name) < f.rejectionAttempts {
+			return i
+		}
+	}
+	return len(f.clients) - 1
+}
@@ -0,0 +1,406 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// staticClient returns a fixed output or error.
+func staticClient(output string, err error) railguard.Client {
+	return railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		return output, err
+	})
+}
+
+func TestNewFallbackClient(t *testing.T) {
+	t.Run("no clients", func(t *testing.T) {
+		_, err := railguard.NewFallbackClient()
+		if !errors.Is(err, railguard.ErrNoClient) {
+			t.Errorf("expected ErrNoClient, got %v", err)
+		}
+	})
+
+	t.Run("nil client", func(t *testing.T) {
+		_, err := railguard.NewFallbackClient(railguard.NamedClient{Name: "primary"})
+		if !errors.Is(err, railguard.ErrNilClient) {
+			t.Errorf("expected ErrNilClient, got %v", err)
+		}
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, clients := range [][]railguard.NamedClient{
+			{{Name: "", Client: &mockClient{}}},
+			{{Name: "primary", Client: &mockClient{}}, {Name: "primary", Client: &mockClient{}}},
+		} {
+			if _, err := railguard.NewFallbackClient(clients...); !errors.Is(err, railguard.ErrInvalidClientName) {
+				t.Errorf("expected ErrInvalidClientName, got %v", err)
+			}
+		}
+	})
+
+	t.Run("clients in order", func(t *testing.T) {
+		f, err := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: &mockClient{}},
+			railguard.NamedClient{Name: "backup", Client: &mockClient{}},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		clients := f.Clients()
+		if len(clients) != 2 || clients[0].Name != "primary" || clients[1].Name != "backup" {
+			t.Errorf("unexpected clients: %+v", clients)
+		}
+	})
+}
+
+func TestFallbackClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("falls back on generation error", func(t *testing.T) {
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("", errors.New("overloaded"))},
+			railguard.NamedClient{Name: "backup", Client: staticClient("backup answer", nil)},
+		)
+
+		g, err := railguard.New(railguard.WithClient(f))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Raw != "backup answer" {
+			t.Errorf("expected backup output, got %q", result.Raw)
+		}
+		if result.Metadata.Attempts != 1 {
+			t.Errorf("expected fallback within one attempt, got %d attempts", result.Metadata.Attempts)
+		}
+		if result.Metadata.Client != "backup" {
+			t.Errorf("expected client 'backup', got %q", result.Metadata.Client)
+		}
+		attempts := result.Metadata.ClientAttempts
+		if attempts["primary"] != 1 || attempts["backup"] != 1 {
+			t.Errorf("unexpected client attempts: %v", attempts)
+		}
+	})
+
+	t.Run("falls back from a hung client under an attempt timeout", func(t *testing.T) {
+		hung := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: hung},
+			railguard.NamedClient{Name: "backup", Client: staticClient("backup answer", nil)},
+		)
+
+		g, err := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithAttemptTimeout(20*time.Millisecond),
+			railguard.WithMaxRetries(1),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Raw != "backup answer" || result.Metadata.Client != "backup" {
+			t.Errorf("expected backup to answer, got %q from %q", result.Raw, result.Metadata.Client)
+		}
+	})
+
+	t.Run("hung clients time out separately", func(t *testing.T) {
+		hung := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: hung},
+			railguard.NamedClient{Name: "backup", Client: hung},
+		)
+
+		g, err := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithAttemptTimeout(10*time.Millisecond),
+			railguard.WithMaxRetries(1),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Run(ctx, "test")
+		var fallbackErr *railguard.FallbackError
+		if !errors.As(err, &fallbackErr) || len(fallbackErr.Errors) != 2 {
+			t.Fatalf("expected FallbackError with 2 errors, got %v", err)
+		}
+		for _, clientErr := range fallbackErr.Errors {
+			if !errors.Is(clientErr, railguard.ErrAttemptTimeout) {
+				t.Errorf("expected ErrAttemptTimeout, got %v", clientErr)
+			}
+		}
+	})
+
+	t.Run("canceled run does not fall back", func(t *testing.T) {
+		runCtx, cancel := context.WithCancel(ctx)
+		calls := 0
+		primary := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			calls++
+			cancel()
+			return "", errors.New("interrupted")
+		})
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: primary},
+			railguard.NamedClient{Name: "backup", Client: staticClient("backup answer", nil)},
+		)
+
+		g, _ := railguard.New(railguard.WithClient(f), railguard.WithAttemptTimeout(time.Second))
+		if _, err := g.Run(runCtx, "test"); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("falls back after rejected outputs", func(t *testing.T) {
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("invalid", nil)},
+			railguard.NamedClient{Name: "backup", Client: staticClient("valid", nil)},
+		)
+		f = f.WithRejectionFallback(2)
+
+		g, err := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if output != "valid" {
+					return errors.New("rejected")
+				}
+				return nil
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 4, Multiplier: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Attempts != 3 || result.Metadata.Client != "backup" {
+			t.Errorf("expected backup to answer on attempt 3, got %+v", result.Metadata)
+		}
+		attempts := result.Metadata.ClientAttempts
+		if attempts["primary"] != 2 || attempts["backup"] != 1 {
+			t.Errorf("unexpected client attempts: %v", attempts)
+		}
+		if result.Metadata.Trace[0].Client != "primary" {
+			t.Errorf("expected first attempt from primary, got %q", result.Metadata.Trace[0].Client)
+		}
+	})
+
+	t.Run("state is per run", func(t *testing.T) {
+		calls := map[string]int{}
+		named := func(name, output string) railguard.NamedClient {
+			return railguard.NamedClient{Name: name, Client: railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				calls[name]++
+				return output, nil
+			})}
+		}
+		f, _ := railguard.NewFallbackClient(named("primary", "invalid"), named("backup", "valid"))
+		f = f.WithRejectionFallback(1)
+
+		g, _ := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if output != "valid" {
+					return errors.New("rejected")
+				}
+				return nil
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+		)
+
+		for i := 0; i < 2; i++ {
+			result, err := g.Run(ctx, "test")
+			if err != nil {
+				t.Fatalf("run %d: unexpected error: %v", i+1, err)
+			}
+			if result.Metadata.Trace[0].Client != "primary" {
+				t.Errorf("run %d: expected each run to start with primary", i+1)
+			}
+		}
+		if calls["primary"] != 2 || calls["backup"] != 2 {
+			t.Errorf("unexpected calls: %v", calls)
+		}
+	})
+
+	t.Run("rejections without fallback keep the primary", func(t *testing.T) {
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("invalid", nil)},
+			railguard.NamedClient{Name: "backup", Client: staticClient("valid", nil)},
+		)
+
+		g, _ := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				return errors.New("rejected")
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 3, Multiplier: 1}),
+		)
+
+		_, err := g.Run(ctx, "test")
+		var maxErr *railguard.MaxRetriesError
+		if !errors.As(err, &maxErr) {
+			t.Fatalf("expected MaxRetriesError, got %v", err)
+		}
+		for _, rec := range maxErr.Trace {
+			if rec.Client != "primary" {
+				t.Errorf("attempt %d: expected primary, got %q", rec.Attempt, rec.Client)
+			}
+		}
+	})
+
+	t.Run("all clients fail", func(t *testing.T) {
+		primaryErr := errors.New("primary down")
+		lastErr := errors.New("backup down")
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("", primaryErr)},
+			railguard.NamedClient{Name: "backup", Client: staticClient("", lastErr)},
+		)
+
+		_, err := f.Generate(ctx, "test")
+		var fallbackErr *railguard.FallbackError
+		if !errors.As(err, &fallbackErr) || len(fallbackErr.Errors) != 2 {
+			t.Fatalf("expected FallbackError with both errors, got %v", err)
+		}
+		if !errors.Is(err, primaryErr) || !errors.Is(err, lastErr) {
+			t.Errorf("expected every client error, got %v", err)
+		}
+		want := "all clients failed: client primary: primary down; client backup: backup down"
+		if err.Error() != want {
+			t.Errorf("expected %q, got %q", want, err.Error())
+		}
+	})
+
+	t.Run("retried if any client error is temporary", func(t *testing.T) {
+		calls := 0
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("", &statusError{code: 401})},
+			railguard.NamedClient{Name: "backup", Client: railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				calls++
+				if calls == 1 {
+					return "", &statusError{code: 503}
+				}
+				return "ok", nil
+			})},
+		)
+		g, _ := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+		)
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("expected retry to reach the backup, got %v", err)
+		}
+		if result.Metadata.Client != "backup" || result.Metadata.Attempts != 2 {
+			t.Errorf("unexpected metadata: %+v", result.Metadata)
+		}
+
+		// Permanent errors from every client are not retried
+		f, _ = railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("", &statusError{code: 401})},
+			railguard.NamedClient{Name: "backup", Client: staticClient("", &statusError{code: 400})},
+		)
+		g, _ = railguard.New(railguard.WithClient(f), railguard.WithMaxRetries(3))
+		if _, err := g.Run(ctx, "test"); errors.As(err, new(*railguard.MaxRetriesError)) {
+			t.Errorf("expected no retries, got %v", err)
+		}
+	})
+
+	t.Run("rejection fallback returns a copy", func(t *testing.T) {
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: staticClient("invalid", nil)},
+			railguard.NamedClient{Name: "backup", Client: staticClient("valid", nil)},
+		)
+		if f.WithRejectionFallback(1) == f {
+			t.Fatal("expected a new client")
+		}
+
+		// The original client still keeps the primary
+		g, _ := railguard.New(
+			railguard.WithClient(f),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				return errors.New("rejected")
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+		)
+		_, err := g.Run(ctx, "test")
+		var maxErr *railguard.MaxRetriesError
+		if !errors.As(err, &maxErr) || maxErr.Trace[1].Client != "primary" {
+			t.Errorf("expected primary on every attempt, got %v", err)
+		}
+	})
+
+	t.Run("stops on canceled context", func(t *testing.T) {
+		backupCalled := false
+		f, _ := railguard.NewFallbackClient(
+			railguard.NamedClient{Name: "primary", Client: railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				return "", ctx.Err()
+			})},
+			railguard.NamedClient{Name: "backup", Client: railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				backupCalled = true
+				return "ok", nil
+			})},
+		)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := f.Generate(canceled, "test"); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if backupCalled {
+			t.Error("expected backup not to be called")
+		}
+	})
+
+	t.Run("keeps chat support", func(t *testing.T) {
+		var got []railguard.Message
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			got = messages
+			return "ok", nil
+		})
+		f, _ := railguard.NewFallbackClient(railguard.NamedClient{Name: "chat", Client: railguard.AsClient(chat)})
+
+		messages := []railguard.Message{
+			{Role: railguard.RoleSystem, Content: "be brief"},
+			{Role: railguard.RoleUser, Content: "hi"},
+		}
+		if _, err := f.Chat(ctx, messages); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 {
+			t.Errorf("expected messages to be passed through, got %+v", got)
+		}
+	})
+
+	t.Run("metadata empty for unnamed clients", func(t *testing.T) {
+		g, _ := railguard.New(railguard.WithClient(&mockClient{}))
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Client != "" || result.Metadata.ClientAttempts != nil {
+			t.Errorf("expected no client metadata, got %+v", result.Metadata)
+		}
+	})
+}
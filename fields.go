@@ -0,0 +1,197 @@
+package railguard
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schemaField is a struct field as seen by encoding/json, including the
+// fields promoted from embedded structs.
+type schemaField struct {
+	// name is the JSON object key of the field.
+	name string
+
+	// goName is the Go name of the field.
+	goName string
+
+	// index is the index sequence for reflect.Value.FieldByIndex.
+	index []int
+
+	// typ is the type of the field.
+	typ reflect.Type
+
+	// tag is the struct tag of the field.
+	tag reflect.StructTag
+
+	// omitEmpty is true if the json tag has the omitempty option.
+	omitEmpty bool
+
+	// quoted is true if the json tag has the string option, which encodes
+	// numbers and booleans as JSON strings.
+	quoted bool
+
+	// tagged is true if the json tag sets the name explicitly.
+	tagged bool
+}
+
+// fieldCache caches the fields of struct types, keyed by reflect.Type.
+var fieldCache sync.Map
+
+// structFields returns the JSON fields of struct type t in encoding/json
+// order, following its rules for embedded structs: fields of embedded
+// structs without a json name are promoted, and a shallower field hides
+// deeper fields with the same name.
+func structFields(t reflect.Type) []schemaField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]schemaField)
+	}
+
+	var all []schemaField
+	collectFields(t, nil, map[reflect.Type]bool{}, &all)
+
+	// Keep the dominant field for each name, as encoding/json does: the
+	// shallowest one, or the only tagged one among equally shallow fields
+	byName := make(map[string][]schemaField)
+	var names []string
+	for _, f := range all {
+		if _, ok := byName[f.name]; !ok {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fields := make([]schemaField, 0, len(names))
+	for _, name := range names {
+		if f, ok := dominantField(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return indexLess(fields[i].index, fields[j].index)
+	})
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]schemaField)
+}
+
+// collectFields appends the fields of t to fields, descending into embedded
+// structs. visited guards against embedding cycles through pointers.
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]schemaField) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous {
+			// Unexported embedded structs still promote their exported fields
+			if !sf.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+		} else if !sf.IsExported() {
+			continue
+		}
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			collectFields(ft, fieldIndex, visited, fields)
+			continue
+		}
+
+		f := schemaField{
+			name:   name,
+			goName: sf.Name,
+			index:  fieldIndex,
+			typ:    sf.Type,
+			tag:    sf.Tag,
+			tagged: name != "",
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for opts != "" {
+			var opt string
+			opt, opts, _ = strings.Cut(opts, ",")
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "string":
+				f.quoted = isQuotable(sf.Type)
+			}
+		}
+		*fields = append(*fields, f)
+	}
+}
+
+// dominantField returns the field that wins among fields with the same name,
+// or false if the name is ambiguous and encoding/json ignores it.
+func dominantField(fields []schemaField) (schemaField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+
+	var winner *schemaField
+	tagged := 0
+	shallow := 0
+	for i := range fields {
+		if len(fields[i].index) != depth {
+			continue
+		}
+		shallow++
+		if fields[i].tagged {
+			tagged++
+			winner = &fields[i]
+		} else if winner == nil {
+			winner = &fields[i]
+		}
+	}
+	if shallow > 1 && tagged != 1 {
+		return schemaField{}, false
+	}
+	return *winner, true
+}
+
+// indexLess orders index sequences by field position.
+func indexLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// isQuotable reports whether the json string option applies to t.
+func isQuotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package railguard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// HedgeConfig configures hedged requests; see WithHedging.
+type HedgeConfig struct {
+	// Delay is how long an attempt waits for the first request before sending
+	// a hedge, e.g. the provider's p95 latency.
+	Delay time.Duration
+
+	// Budget is the maximum fraction of attempts that may send a hedge,
+	// between 0 and 1. For example, 0.1 limits the extra requests to 10% of
+	// the attempts made by the Guard, so hedging cannot double the spend.
+	Budget float64
+}
+
+// Validate checks that the hedge configuration is valid.
+func (c HedgeConfig) Validate() error {
+	if c.Delay <= 0 {
+		return errors.New("delay must be positive")
+	}
+	if c.Budget <= 0 || c.Budget > 1 {
+		return errors.New("budget must be between 0 and 1")
+	}
+	return nil
+}
+
+// hedgeBudget tracks the hedges sent by a Guard against its attempts.
+type hedgeBudget struct {
+	mu       sync.Mutex
+	ratio    float64
+	attempts int64
+	hedges   int64
+}
+
+// recordAttempt counts an attempt that may send a hedge.
+func (b *hedgeBudget) recordAttempt() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts++
+}
+
+// allowHedge reports whether another hedge fits in the budget and counts it.
+// A hedge must fit in the fraction of attempts made so far, so with a budget
+// of 0.1 the first hedge is allowed on the 10th attempt.
+func (b *hedgeBudget) allowHedge() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if float64(b.hedges+1) > b.ratio*float64(b.attempts) {
+		return false
+	}
+	b.hedges++
+	return true
+}
+
+// hedgeResult is the outcome of one request of a hedged attempt.
+type hedgeResult struct {
+	record      AttemptRecord
+	transformed string
+	parsed      interface{}
+	state       *runState
+}
+
+// hedgedAttempt performs an attempt that sends a second request if the first
+// has not produced an accepted output within the hedge delay. The first
+// output that passes transformers, validators, and the schema wins, and the
+// other request is canceled. If both requests fail, the first failure is returned.
+//
+// Each request runs with its own run state, and only the state of the
+// returned request is merged into the run. The attempt returns once the
+// other request has stopped, so it cannot report events after the attempt.
+func (g *Guard) hedgedAttempt(ctx context.Context, attempt int, messages []Message) (AttemptRecord, string, interface{}) {
+	g.hedgeBudget.recordAttempt()
+	state := runStateFrom(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	send := func() {
+		child := state.fork()
+		go func() {
+			record, transformed, parsed := g.try(context.WithValue(ctx, runStateKey{}, child), attempt, messages)
+			results <- hedgeResult{record: record, transformed: transformed, parsed: parsed, state: child}
+		}()
+	}
+
+	send()
+	pending := 1
+	hedged := false
+
+	timer := time.NewTimer(g.hedge.Delay)
+	defer timer.Stop()
+
+	// A failure before the delay leaves nothing to hedge; the loop ends and
+	// the retry loop decides what to do
+	var won, failed *hedgeResult
+	for won == nil && pending > 0 {
+		select {
+		case <-timer.C:
+			if g.hedgeBudget.allowHedge() {
+				send()
+				pending++
+				hedged = true
+			}
+		case res := <-results:
+			pending--
+			if res.record.Err == nil {
+				won = &res
+			} else if failed == nil {
+				failed = &res
+			}
+		}
+	}
+
+	// Cancel the other request and wait for it to stop
+	cancel()
+	for ; pending > 0; pending-- {
+		<-results
+	}
+
+	if won == nil {
+		state.merge(failed.state)
+		failed.record.Hedged = hedged
+		return failed.record, "", nil
+	}
+	state.merge(won.state)
+	won.record.Hedged = hedged
+	return won.record, won.transformed, won.parsed
+}
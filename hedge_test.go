@@ -0,0 +1,238 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// slowFirstClient blocks its first call until canceled and answers later calls immediately.
+type slowFirstClient struct {
+	calls    atomic.Int32
+	canceled chan struct{}
+}
+
+func newSlowFirstClient() *slowFirstClient {
+	return &slowFirstClient{canceled: make(chan struct{})}
+}
+
+func (c *slowFirstClient) Generate(ctx context.Context, prompt string) (string, error) {
+	if c.calls.Add(1) == 1 {
+		<-ctx.Done()
+		close(c.canceled)
+		return "", ctx.Err()
+	}
+	return "hedged", nil
+}
+
+func TestWithHedging(t *testing.T) {
+	invalid := []struct {
+		name   string
+		config railguard.HedgeConfig
+	}{
+		{"zero delay", railguard.HedgeConfig{Budget: 0.1}},
+		{"zero budget", railguard.HedgeConfig{Delay: time.Millisecond}},
+		{"budget above 1", railguard.HedgeConfig{Delay: time.Millisecond, Budget: 2}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := railguard.New(
+				railguard.WithClient(&mockClient{}),
+				railguard.WithHedging(tt.config),
+			)
+			if !errors.Is(err, railguard.ErrInvalidHedgeConfig) {
+				t.Errorf("expected ErrInvalidHedgeConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHedging(t *testing.T) {
+	ctx := context.Background()
+	config := railguard.HedgeConfig{Delay: 10 * time.Millisecond, Budget: 1}
+
+	t.Run("hedge answers and cancels slow request", func(t *testing.T) {
+		client := newSlowFirstClient()
+		g, err := railguard.New(railguard.WithClient(client), railguard.WithHedging(config))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Raw != "hedged" {
+			t.Errorf("expected hedged output, got %q", result.Raw)
+		}
+		if result.Metadata.Attempts != 1 || result.Metadata.Hedges != 1 {
+			t.Errorf("expected 1 attempt with 1 hedge, got %+v", result.Metadata)
+		}
+		if !result.Metadata.Trace[0].Hedged {
+			t.Error("expected attempt to be marked as hedged")
+		}
+
+		select {
+		case <-client.canceled:
+		case <-time.After(time.Second):
+			t.Error("expected slow request to be canceled")
+		}
+	})
+
+	t.Run("fast request is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			calls.Add(1)
+			return "ok", nil
+		})
+		g, _ := railguard.New(railguard.WithClient(client), railguard.WithHedging(config))
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls.Load() != 1 || result.Metadata.Hedges != 0 {
+			t.Errorf("expected no hedge, got %d calls and %d hedges", calls.Load(), result.Metadata.Hedges)
+		}
+	})
+
+	t.Run("first accepted output wins", func(t *testing.T) {
+		var calls atomic.Int32
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			if calls.Add(1) == 1 {
+				time.Sleep(30 * time.Millisecond)
+				return "valid", nil
+			}
+			return "invalid", nil
+		})
+		g, _ := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithHedging(config),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if output != "valid" {
+					return errors.New("rejected")
+				}
+				return nil
+			})),
+			railguard.WithMaxRetries(1),
+		)
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Raw != "valid" {
+			t.Errorf("expected the accepted output, got %q", result.Raw)
+		}
+	})
+
+	t.Run("both requests fail", func(t *testing.T) {
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			time.Sleep(20 * time.Millisecond)
+			return "", errors.New("overloaded")
+		})
+		g, _ := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithHedging(config),
+			railguard.WithMaxRetries(1),
+		)
+
+		_, err := g.Run(ctx, "test")
+		var maxErr *railguard.MaxRetriesError
+		if !errors.As(err, &maxErr) {
+			t.Fatalf("expected MaxRetriesError, got %v", err)
+		}
+		if !maxErr.Trace[0].Hedged {
+			t.Error("expected failed attempt to be marked as hedged")
+		}
+		var genErr *railguard.GenerationError
+		if !errors.As(err, &genErr) {
+			t.Errorf("expected GenerationError, got %v", err)
+		}
+	})
+
+	t.Run("losing request does not outlive the attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				// Winds down slowly after cancellation
+				time.Sleep(20 * time.Millisecond)
+				railguard.SetStopReason(ctx, "canceled")
+				return "", ctx.Err()
+			}
+			railguard.SetStopReason(ctx, "end_turn")
+			return "hedged", nil
+		})
+		observer := &recordingObserver{}
+		g, _ := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithHedging(config),
+			railguard.WithObserver(observer),
+		)
+
+		result, err := g.Run(ctx, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.StopReason != "end_turn" {
+			t.Errorf("expected the winner's stop reason, got %q", result.Metadata.StopReason)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		if last := observer.events[len(observer.events)-1]; last != "run end attempts=1 err=false" {
+			t.Errorf("expected run end to be the last event, got %v", observer.events)
+		}
+	})
+
+	t.Run("budget needs attempts before the first hedge", func(t *testing.T) {
+		g, _ := railguard.New(
+			railguard.WithClient(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				time.Sleep(20 * time.Millisecond)
+				return "ok", nil
+			})),
+			railguard.WithHedging(railguard.HedgeConfig{Delay: time.Millisecond, Budget: 0.25}),
+		)
+
+		var hedges []int
+		for i := 0; i < 4; i++ {
+			result, err := g.Run(ctx, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hedges = append(hedges, result.Metadata.Hedges)
+		}
+		if !reflect.DeepEqual(hedges, []int{0, 0, 0, 1}) {
+			t.Errorf("expected only the 4th attempt to hedge, got %v", hedges)
+		}
+	})
+
+	t.Run("budget limits hedges", func(t *testing.T) {
+		g, _ := railguard.New(
+			railguard.WithClient(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				time.Sleep(20 * time.Millisecond)
+				return "ok", nil
+			})),
+			railguard.WithHedging(railguard.HedgeConfig{Delay: time.Millisecond, Budget: 0.5}),
+		)
+
+		hedges := 0
+		for i := 0; i < 4; i++ {
+			result, err := g.Run(ctx, "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hedges += result.Metadata.Hedges
+		}
+		if hedges != 2 {
+			t.Errorf("expected budget to allow 2 hedges in 4 attempts, got %d", hedges)
+		}
+	})
+}
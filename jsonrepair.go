@@ -0,0 +1,422 @@
+package railguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONFix names a defect fixed by RepairJSON.
+type JSONFix string
+
+const (
+	// FixTrailingComma means a comma before a closing } or ] was removed.
+	FixTrailingComma JSONFix = "trailing_comma"
+
+	// FixSingleQuotes means a single-quoted string was converted to a
+	// double-quoted one.
+	FixSingleQuotes JSONFix = "single_quotes"
+
+	// FixUnquotedKey means an object key written as a bare identifier was
+	// quoted.
+	FixUnquotedKey JSONFix = "unquoted_key"
+
+	// FixComment means a // line comment or /* block */ comment was removed.
+	FixComment JSONFix = "comment"
+
+	// FixPythonLiteral means a Python True, False, or None was replaced by
+	// true, false, or null.
+	FixPythonLiteral JSONFix = "python_literal"
+
+	// FixTruncated means the output ended inside an object or array, which
+	// was closed. Members whose value was cut off are dropped.
+	FixTruncated JSONFix = "truncated"
+)
+
+// maxRepairDepth limits the nesting of objects and arrays RepairJSON accepts.
+const maxRepairDepth = 1000
+
+// RepairJSON fixes common defects of almost-JSON produced by language models:
+// trailing commas, single-quoted strings, unquoted keys, comments, Python
+// True/False/None, and objects or arrays truncated at the end of the output.
+// It returns the repaired JSON and the fixes applied, in the order they were
+// first needed.
+//
+// Valid JSON is returned unchanged with no fixes, so repair never changes the
+// meaning of valid JSON. Defects other than the ones above, such as trailing
+// data or a truncated top-level string, are not guessed at: RepairJSON
+// returns an error wrapping ErrUnrepairableJSON.
+//
+// Example:
+//
+//	repaired, fixes, err := railguard.RepairJSON([]byte(`{name: 'Ada', admin: True,}`))
+//	// repaired: {"name":"Ada","admin":true}
+//	// fixes: [unquoted_key single_quotes python_literal trailing_comma]
+func RepairJSON(data []byte) ([]byte, []JSONFix, error) {
+	if json.Valid(data) {
+		return data, nil, nil
+	}
+
+	r := &jsonRepairer{data: data}
+	r.skipSpace()
+	cut, err := r.value(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cut {
+		return nil, nil, r.errorf("unexpected end of JSON input")
+	}
+	r.skipSpace()
+	if r.pos < len(r.data) {
+		return nil, nil, r.errorf("trailing data after JSON")
+	}
+
+	repaired := r.out.Bytes()
+	if !json.Valid(repaired) {
+		// e.g. an invalid number or escape sequence, which are not repaired
+		return nil, nil, fmt.Errorf("%w: invalid JSON after repair", ErrUnrepairableJSON)
+	}
+	return repaired, r.fixes, nil
+}
+
+// jsonRepairer rewrites almost-JSON into JSON in a single pass.
+type jsonRepairer struct {
+	data  []byte
+	pos   int
+	out   bytes.Buffer
+	fixes []JSONFix
+}
+
+// fix records that a fix was applied.
+func (r *jsonRepairer) fix(fix JSONFix) {
+	for _, f := range r.fixes {
+		if f == fix {
+			return
+		}
+	}
+	r.fixes = append(r.fixes, fix)
+}
+
+// errorf returns an error wrapping ErrUnrepairableJSON at the current offset.
+func (r *jsonRepairer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: offset %d: %s", ErrUnrepairableJSON, r.pos, fmt.Sprintf(format, args...))
+}
+
+// eof reports whether the whole input was consumed.
+func (r *jsonRepairer) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+// skipSpace skips whitespace and comments. An unterminated block comment
+// runs to the end of the input.
+func (r *jsonRepairer) skipSpace() {
+	for !r.eof() {
+		switch {
+		case isSpace(r.data[r.pos]):
+			r.pos++
+		case bytes.HasPrefix(r.data[r.pos:], []byte("//")):
+			r.fix(FixComment)
+			end := bytes.IndexByte(r.data[r.pos:], '\n')
+			if end < 0 {
+				r.pos = len(r.data)
+			} else {
+				r.pos += end + 1
+			}
+		case bytes.HasPrefix(r.data[r.pos:], []byte("/*")):
+			r.fix(FixComment)
+			end := bytes.Index(r.data[r.pos+2:], []byte("*/"))
+			if end < 0 {
+				r.pos = len(r.data)
+			} else {
+				r.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// value rewrites the value at the current position. It reports cut if the
+// input ended inside the value, in which case the partial output must be
+// discarded by the caller. Objects and arrays are never cut: they are closed
+// with the members read so far.
+func (r *jsonRepairer) value(depth int) (cut bool, err error) {
+	if r.eof() {
+		return true, nil
+	}
+	if depth > maxRepairDepth {
+		return false, r.errorf("exceeded max depth")
+	}
+
+	switch c := r.data[r.pos]; {
+	case c == '{':
+		return false, r.object(depth)
+	case c == '[':
+		return false, r.array(depth)
+	case c == '"' || c == '\'':
+		return r.string()
+	case c == '-' || isDigit(c):
+		return r.number(), nil
+	case isIdentStart(c):
+		return r.literal()
+	default:
+		return false, r.errorf("invalid character %q looking for beginning of value", c)
+	}
+}
+
+// object rewrites an object.
+func (r *jsonRepairer) object(depth int) error {
+	r.pos++
+	r.out.WriteByte('{')
+
+	for first := true; ; first = false {
+		r.skipSpace()
+		if r.eof() {
+			r.fix(FixTruncated)
+			break
+		}
+		if r.data[r.pos] == '}' {
+			r.pos++
+			break
+		}
+		if !first {
+			if r.data[r.pos] != ',' {
+				return r.errorf("invalid character %q after object key:value pair", r.data[r.pos])
+			}
+			r.pos++
+			r.skipSpace()
+			if r.eof() {
+				r.fix(FixTruncated)
+				break
+			}
+			if r.data[r.pos] == '}' {
+				r.fix(FixTrailingComma)
+				r.pos++
+				break
+			}
+		}
+
+		// Members cut off by the end of the input are dropped
+		mark := r.out.Len()
+		if !first {
+			r.out.WriteByte(',')
+		}
+		cut, err := r.key()
+		if err != nil {
+			return err
+		}
+		if !cut {
+			r.skipSpace()
+			switch {
+			case r.eof():
+				cut = true
+			case r.data[r.pos] != ':':
+				return r.errorf("invalid character %q after object key", r.data[r.pos])
+			default:
+				r.pos++
+				r.out.WriteByte(':')
+				r.skipSpace()
+				if cut, err = r.value(depth + 1); err != nil {
+					return err
+				}
+			}
+		}
+		if cut {
+			r.out.Truncate(mark)
+			r.fix(FixTruncated)
+			break
+		}
+	}
+
+	r.out.WriteByte('}')
+	return nil
+}
+
+// array rewrites an array.
+func (r *jsonRepairer) array(depth int) error {
+	r.pos++
+	r.out.WriteByte('[')
+
+	for first := true; ; first = false {
+		r.skipSpace()
+		if r.eof() {
+			r.fix(FixTruncated)
+			break
+		}
+		if r.data[r.pos] == ']' {
+			r.pos++
+			break
+		}
+		if !first {
+			if r.data[r.pos] != ',' {
+				return r.errorf("invalid character %q after array element", r.data[r.pos])
+			}
+			r.pos++
+			r.skipSpace()
+			if r.eof() {
+				r.fix(FixTruncated)
+				break
+			}
+			if r.data[r.pos] == ']' {
+				r.fix(FixTrailingComma)
+				r.pos++
+				break
+			}
+		}
+
+		mark := r.out.Len()
+		if !first {
+			r.out.WriteByte(',')
+		}
+		cut, err := r.value(depth + 1)
+		if err != nil {
+			return err
+		}
+		if cut {
+			r.out.Truncate(mark)
+			r.fix(FixTruncated)
+			break
+		}
+	}
+
+	r.out.WriteByte(']')
+	return nil
+}
+
+// key rewrites an object key, quoting bare identifiers.
+func (r *jsonRepairer) key() (cut bool, err error) {
+	c := r.data[r.pos]
+	if c == '"' || c == '\'' {
+		return r.string()
+	}
+	if !isIdentStart(c) {
+		return false, r.errorf("invalid character %q looking for beginning of object key string", c)
+	}
+
+	start := r.pos
+	for !r.eof() && isIdentPart(r.data[r.pos]) {
+		r.pos++
+	}
+	if r.eof() {
+		return true, nil
+	}
+	r.fix(FixUnquotedKey)
+	quoted, _ := json.Marshal(string(r.data[start:r.pos]))
+	r.out.Write(quoted)
+	return false, nil
+}
+
+// string rewrites a double- or single-quoted string. Escape sequences are
+// copied as they are, except \' which is not valid JSON.
+func (r *jsonRepairer) string() (cut bool, err error) {
+	quote := r.data[r.pos]
+	if quote == '\'' {
+		r.fix(FixSingleQuotes)
+	}
+	r.pos++
+	r.out.WriteByte('"')
+
+	for !r.eof() {
+		c := r.data[r.pos]
+		switch {
+		case c == quote:
+			r.pos++
+			r.out.WriteByte('"')
+			return false, nil
+		case c == '\\':
+			if r.pos+1 >= len(r.data) {
+				return true, nil
+			}
+			if next := r.data[r.pos+1]; next == '\'' {
+				r.out.WriteByte('\'')
+			} else {
+				r.out.Write(r.data[r.pos : r.pos+2])
+			}
+			r.pos += 2
+		case c == '"':
+			// Only reachable in single-quoted strings
+			r.out.WriteString(`\"`)
+			r.pos++
+		default:
+			r.out.WriteByte(c)
+			r.pos++
+		}
+	}
+	return true, nil
+}
+
+// number copies a number. A number at the end of the input is reported as
+// cut, since more digits may have followed. Invalid numbers are caught by
+// the final validity check.
+func (r *jsonRepairer) number() (cut bool) {
+	start := r.pos
+	for !r.eof() && isNumberPart(r.data[r.pos]) {
+		r.pos++
+	}
+	r.out.Write(r.data[start:r.pos])
+	return r.eof()
+}
+
+// literal rewrites true, false, and null, and their Python spellings.
+func (r *jsonRepairer) literal() (cut bool, err error) {
+	start := r.pos
+	for !r.eof() && isIdentPart(r.data[r.pos]) {
+		r.pos++
+	}
+
+	word := string(r.data[start:r.pos])
+	switch word {
+	case "true", "false", "null":
+		r.out.WriteString(word)
+		return false, nil
+	case "True", "False", "None":
+		r.fix(FixPythonLiteral)
+		r.out.WriteString(pythonLiterals[word])
+		return false, nil
+	}
+	if r.eof() && isLiteralPrefix(word) {
+		return true, nil
+	}
+	r.pos = start
+	return false, r.errorf("invalid literal %q", word)
+}
+
+// pythonLiterals maps Python literals to their JSON spelling.
+var pythonLiterals = map[string]string{"True": "true", "False": "false", "None": "null"}
+
+// isLiteralPrefix reports whether word is the start of a literal, as left by
+// an output that was cut off.
+func isLiteralPrefix(word string) bool {
+	for _, literal := range []string{"true", "false", "null", "True", "False", "None"} {
+		if strings.HasPrefix(literal, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpace reports whether c is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isNumberPart reports whether c may appear in a JSON number.
+func isNumberPart(c byte) bool {
+	return isDigit(c) || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+// isIdentStart reports whether c may start a bare key or literal.
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$'
+}
+
+// isIdentPart reports whether c may continue a bare key or literal.
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
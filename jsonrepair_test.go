@@ -0,0 +1,133 @@
+package railguard_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		fixes []railguard.JSONFix
+	}{
+		{
+			name:  "trailing commas",
+			input: `{"a": [1, 2,], "b": {"c": true,},}`,
+			want:  `{"a":[1,2],"b":{"c":true}}`,
+			fixes: []railguard.JSONFix{railguard.FixTrailingComma},
+		},
+		{
+			name:  "single quotes",
+			input: `{'name': 'Ada "the" Countess', 'note': 'it\'s'}`,
+			want:  `{"name":"Ada \"the\" Countess","note":"it's"}`,
+			fixes: []railguard.JSONFix{railguard.FixSingleQuotes},
+		},
+		{
+			name:  "unquoted keys",
+			input: `{name: "Ada", $id: 1, first_name: "A"}`,
+			want:  `{"name":"Ada","$id":1,"first_name":"A"}`,
+			fixes: []railguard.JSONFix{railguard.FixUnquotedKey},
+		},
+		{
+			name:  "comments",
+			input: "// result\n{\"a\": 1, /* inline */ \"b\": \"// not a comment\"} // done",
+			want:  `{"a":1,"b":"// not a comment"}`,
+			fixes: []railguard.JSONFix{railguard.FixComment},
+		},
+		{
+			name:  "python literals",
+			input: `{"ok": True, "failed": False, "error": None, "text": "True"}`,
+			want:  `{"ok":true,"failed":false,"error":null,"text":"True"}`,
+			fixes: []railguard.JSONFix{railguard.FixPythonLiteral},
+		},
+		{
+			name:  "truncated object",
+			input: `{"name": "Ada", "tags": ["a", "b"`,
+			want:  `{"name":"Ada","tags":["a","b"]}`,
+			fixes: []railguard.JSONFix{railguard.FixTruncated},
+		},
+		{
+			name:  "truncated inside string drops member",
+			input: `{"name": "Ada", "bio": "Mathematician and wri`,
+			want:  `{"name":"Ada"}`,
+			fixes: []railguard.JSONFix{railguard.FixTruncated},
+		},
+		{
+			name:  "truncated number drops member",
+			input: `{"name": "Ada", "born": 18`,
+			want:  `{"name":"Ada"}`,
+			fixes: []railguard.JSONFix{railguard.FixTruncated},
+		},
+		{
+			name:  "truncated after key",
+			input: `{"name": "Ada", "born":`,
+			want:  `{"name":"Ada"}`,
+			fixes: []railguard.JSONFix{railguard.FixTruncated},
+		},
+		{
+			name:  "truncated nested",
+			input: `[{"a": {"b": [1, tr`,
+			want:  `[{"a":{"b":[1]}}]`,
+			fixes: []railguard.JSONFix{railguard.FixTruncated},
+		},
+		{
+			name:  "several fixes in order",
+			input: `{name: 'Ada', admin: True,}`,
+			want:  `{"name":"Ada","admin":true}`,
+			fixes: []railguard.JSONFix{railguard.FixUnquotedKey, railguard.FixSingleQuotes, railguard.FixPythonLiteral, railguard.FixTrailingComma},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repaired, fixes, err := railguard.RepairJSON([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(repaired) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, repaired)
+			}
+			if !reflect.DeepEqual(fixes, tt.fixes) {
+				t.Errorf("expected fixes %v, got %v", tt.fixes, fixes)
+			}
+		})
+	}
+}
+
+func TestRepairJSONValidInput(t *testing.T) {
+	// Valid JSON is returned byte for byte, even where a repair would apply
+	for _, input := range []string{
+		`{"a": "it's", "b": "// x", "c": [1, 2]}`,
+		`"True"`,
+		`12`,
+		`null`,
+	} {
+		repaired, fixes, err := railguard.RepairJSON([]byte(input))
+		if err != nil || string(repaired) != input || fixes != nil {
+			t.Errorf("%s: expected unchanged input, got %s, %v, %v", input, repaired, fixes, err)
+		}
+	}
+}
+
+func TestRepairJSONUnrepairable(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{"a": 1} {"b": 2}`,
+		`{"a": undefined}`,
+		`{"a": NaN}`,
+		`{"a" 1}`,
+		`{"a": 1 "b": 2}`,
+		`"truncated string`,
+		`{"a": 01}`,
+		`{"a": "\x41"}`,
+	} {
+		if _, _, err := railguard.RepairJSON([]byte(input)); !errors.Is(err, railguard.ErrUnrepairableJSON) {
+			t.Errorf("%s: expected ErrUnrepairableJSON, got %v", input, err)
+		}
+	}
+}
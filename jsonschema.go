@@ -0,0 +1,365 @@
+package railguard
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema draft produced by Schema.JSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	numberType          = reflect.TypeOf(json.Number(""))
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) document describing the
+// JSON accepted by the schema, for use with the structured output and tool
+// APIs of LLM providers. It is derived from TargetType:
+//
+//   - Properties follow the json tags of the struct, in field order,
+//     including the fields promoted from embedded structs.
+//   - Required fields are listed as required; see WithRequiredFields.
+//   - Constraints of railguard tags map to keywords such as minimum,
+//     maxLength, enum, and pattern.
+//   - Pointers are nullable unless the field is required, time.Time is a
+//     date-time string, and []byte is a base64 string.
+//   - The description and example struct tags set a property's description
+//     and examples. Examples are parsed as JSON, except for string fields.
+//   - In strict mode, every object sets additionalProperties to false.
+//
+// Recursive types are described with $defs and $ref.
+//
+// Example:
+//
+//	type Invoice struct {
+//	    ID     string  `json:"id" description:"Invoice number" example:"INV-001"`
+//	    Amount float64 `json:"amount"`
+//	}
+//	schema, _ := railguard.NewSchema(&Invoice{})
+//	doc, err := schema.JSONSchema()
+func (s *Schema) JSONSchema() (json.RawMessage, error) {
+	g := &schemaGenerator{
+		root:             s.targetType,
+		strict:           s.strict,
+		implicitRequired: s.requiredFields,
+		refs:             make(map[reflect.Type]string),
+		names:            make(map[string]bool),
+		active:           make(map[reflect.Type]bool),
+	}
+
+	root, err := g.schemaOf(s.targetType)
+	if err != nil {
+		return nil, err
+	}
+	root.Dialect = JSONSchemaDialect
+	if len(g.defs.names) > 0 {
+		root.Defs = &g.defs
+	}
+	return json.Marshal(root)
+}
+
+// jsonSchema is a JSON Schema document or subschema.
+type jsonSchema struct {
+	Dialect              string        `json:"$schema,omitempty"`
+	Ref                  string        `json:"$ref,omitempty"`
+	Type                 interface{}   `json:"type,omitempty"`
+	Format               string        `json:"format,omitempty"`
+	ContentEncoding      string        `json:"contentEncoding,omitempty"`
+	Description          string        `json:"description,omitempty"`
+	Properties           *schemaMap    `json:"properties,omitempty"`
+	Required             []string      `json:"required,omitempty"`
+	AdditionalProperties interface{}   `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema   `json:"items,omitempty"`
+	MinItems             *int          `json:"minItems,omitempty"`
+	MaxItems             *int          `json:"maxItems,omitempty"`
+	MinProperties        *int          `json:"minProperties,omitempty"`
+	MaxProperties        *int          `json:"maxProperties,omitempty"`
+	MinLength            *int          `json:"minLength,omitempty"`
+	MaxLength            *int          `json:"maxLength,omitempty"`
+	Minimum              *float64      `json:"minimum,omitempty"`
+	Maximum              *float64      `json:"maximum,omitempty"`
+	Pattern              string        `json:"pattern,omitempty"`
+	Enum                 []interface{} `json:"enum,omitempty"`
+	AnyOf                []*jsonSchema `json:"anyOf,omitempty"`
+	Examples             []interface{} `json:"examples,omitempty"`
+	Defs                 *schemaMap    `json:"$defs,omitempty"`
+}
+
+// schemaMap is a JSON object of subschemas that keeps insertion order, so
+// properties are listed in field order.
+type schemaMap struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+// set adds or replaces the subschema for name.
+func (m *schemaMap) set(name string, schema *jsonSchema) {
+	if m.schemas == nil {
+		m.schemas = make(map[string]*jsonSchema)
+	}
+	if _, ok := m.schemas[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.schemas[name] = schema
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *schemaMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range m.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// schemaGenerator builds the JSON Schema of a Go type.
+type schemaGenerator struct {
+	root             reflect.Type
+	strict           bool
+	implicitRequired bool
+
+	// refs holds the $ref of each struct type found to be recursive.
+	refs map[reflect.Type]string
+
+	// names holds the $defs names in use.
+	names map[string]bool
+
+	// active holds the struct types currently being generated.
+	active map[reflect.Type]bool
+
+	defs schemaMap
+}
+
+// schemaOf returns the schema of t.
+func (g *schemaGenerator) schemaOf(t reflect.Type) (*jsonSchema, error) {
+	switch t {
+	case timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}, nil
+	case rawMessageType:
+		return &jsonSchema{}, nil
+	case numberType:
+		return &jsonSchema{Type: "number"}, nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		elem, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(elem), nil
+	}
+
+	// Types that decode themselves accept JSON we cannot describe, unless
+	// they decode from text
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(jsonUnmarshalerType) {
+		return &jsonSchema{}, nil
+	}
+	if ptr.Implements(textUnmarshalerType) {
+		return &jsonSchema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &jsonSchema{Type: "integer", Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Interface:
+		return &jsonSchema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(textUnmarshalerType) {
+			return &jsonSchema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := &jsonSchema{Type: "array", Items: items}
+		if t.Kind() == reflect.Array {
+			n := t.Len()
+			schema.MinItems, schema.MaxItems = &n, &n
+		}
+		return schema, nil
+	case reflect.Map:
+		if !isMapKey(t.Key()) {
+			return nil, fmt.Errorf("%w: unsupported map key type %v", ErrInvalidSchema, t.Key())
+		}
+		values, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %v", ErrInvalidSchema, t)
+	}
+}
+
+// structSchema returns the schema of struct type t. A type that refers to
+// itself is moved to $defs and referenced, or referenced as "#" if it is the
+// root type.
+func (g *schemaGenerator) structSchema(t reflect.Type) (*jsonSchema, error) {
+	if ref, ok := g.refs[t]; ok {
+		return &jsonSchema{Ref: ref}, nil
+	}
+	if g.active[t] {
+		g.refs[t] = g.refFor(t)
+		return &jsonSchema{Ref: g.refs[t]}, nil
+	}
+	g.active[t] = true
+	defer delete(g.active, t)
+
+	schema := &jsonSchema{Type: "object", Properties: &schemaMap{}}
+	constraints := constraintsOf(t)
+	for i, f := range structFields(t) {
+		required := isRequired(f, constraints[i], g.implicitRequired)
+		prop, err := g.fieldSchema(f, constraints[i], required)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.goName, err)
+		}
+		schema.Properties.set(f.name, prop)
+		if required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+	if g.strict {
+		schema.AdditionalProperties = false
+	}
+
+	if ref, ok := g.refs[t]; ok && t != g.root {
+		g.defs.set(ref[len("#/$defs/"):], schema)
+		return &jsonSchema{Ref: ref}, nil
+	}
+	return schema, nil
+}
+
+// fieldSchema returns the schema of a struct field, with its description,
+// example, and railguard tags applied. Required fields reject null, so a
+// required pointer field is not nullable.
+func (g *schemaGenerator) fieldSchema(f schemaField, fc fieldConstraints, required bool) (*jsonSchema, error) {
+	typ := f.typ
+	if required {
+		typ = indirect(typ)
+	}
+
+	var schema *jsonSchema
+	if f.quoted {
+		schema = &jsonSchema{Type: "string"}
+		if typ.Kind() == reflect.Ptr {
+			schema = nullable(schema)
+		}
+	} else {
+		var err error
+		if schema, err = g.schemaOf(typ); err != nil {
+			return nil, err
+		}
+	}
+
+	// Quoted numbers are strings in JSON, so numeric keywords do not apply
+	if !f.quoted {
+		for _, rule := range fc.rules {
+			switch {
+			case !rule.elem:
+				rule.schema(schema)
+			case schema.Items != nil:
+				rule.schema(schema.Items)
+			}
+		}
+	}
+
+	schema.Description = f.tag.Get("description")
+	if example, ok := f.tag.Lookup("example"); ok {
+		schema.Examples = []interface{}{parseExample(example, f)}
+	}
+	return schema, nil
+}
+
+// refFor returns the $ref for a recursive struct type, picking a unique
+// $defs name based on the type name.
+func (g *schemaGenerator) refFor(t reflect.Type) string {
+	if t == g.root {
+		return "#"
+	}
+	base := t.Name()
+	if base == "" {
+		base = "object"
+	}
+	name := base
+	for i := 2; g.names[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.names[name] = true
+	return "#/$defs/" + name
+}
+
+// nullable returns schema extended to also accept null.
+func nullable(schema *jsonSchema) *jsonSchema {
+	if typ, ok := schema.Type.(string); ok && schema.Ref == "" {
+		schema.Type = []string{typ, "null"}
+		return schema
+	}
+	if schema.Type == nil && schema.Ref == "" {
+		// Already accepts anything, including null
+		return schema
+	}
+	return &jsonSchema{AnyOf: []*jsonSchema{schema, {Type: "null"}}}
+}
+
+// parseExample returns the value of an example tag: the tag itself for
+// string fields, or the tag parsed as JSON for other fields.
+func parseExample(example string, f schemaField) interface{} {
+	t := f.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String || f.quoted || t == timeType {
+		return example
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(example), &value); err != nil {
+		return example
+	}
+	return value
+}
+
+// isMapKey reports whether encoding/json supports maps with keys of type t.
+func isMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
@@ -0,0 +1,225 @@
+package railguard_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// assertJSONSchema checks that the JSON Schema of schema equals want, ignoring whitespace.
+func assertJSONSchema(t *testing.T, schema *railguard.Schema, want string) {
+	t.Helper()
+
+	got, err := schema.JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(want)); err != nil {
+		t.Fatalf("invalid expected schema: %v", err)
+	}
+	if string(got) != compact.String() {
+		t.Errorf("unexpected schema:\ngot:  %s\nwant: %s", got, compact.String())
+	}
+}
+
+type audit struct {
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type invoiceLine struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount" example:"12.5"`
+}
+
+type invoice struct {
+	audit
+	ID       string            `json:"id" description:"Invoice number" example:"INV-001"`
+	Customer *string           `json:"customer"`
+	Lines    []invoiceLine     `json:"lines"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Paid     bool              `json:"paid"`
+	Count    uint              `json:"count,string"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	Skipped  string            `json:"-"`
+	internal string
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	t.Run("strict", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&invoice{})
+		assertJSONSchema(t, schema, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"created_at": {"type": "string", "format": "date-time"},
+				"deleted_at": {"type": ["string", "null"], "format": "date-time"},
+				"id": {"type": "string", "description": "Invoice number", "examples": ["INV-001"]},
+				"customer": {"type": ["string", "null"]},
+				"lines": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"description": {"type": "string"},
+							"amount": {"type": "number", "examples": [12.5]}
+						},
+						"required": ["description", "amount"],
+						"additionalProperties": false
+					}
+				},
+				"tags": {"type": "object", "additionalProperties": {"type": "string"}},
+				"paid": {"type": "boolean"},
+				"count": {"type": "string"},
+				"raw": {},
+				"data": {"type": "string", "contentEncoding": "base64"}
+			},
+			"required": ["created_at", "id", "lines", "paid", "count"],
+			"additionalProperties": false
+		}`)
+	})
+
+	t.Run("non-strict", func(t *testing.T) {
+		schema, _ := railguard.NewSchema(&invoiceLine{})
+		assertJSONSchema(t, schema.WithStrict(false), `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"description": {"type": "string"},
+				"amount": {"type": "number", "examples": [12.5]}
+			},
+			"required": ["description", "amount"]
+		}`)
+	})
+
+	t.Run("embedded field hidden by outer field", func(t *testing.T) {
+		type Base struct {
+			ID   int    `json:"id"`
+			Kind string `json:"kind"`
+		}
+		type Item struct {
+			Base
+			ID    string `json:"id"`
+			Score [2]int `json:"score"`
+		}
+		schema, _ := railguard.NewSchema(&Item{})
+		assertJSONSchema(t, schema, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"kind": {"type": "string"},
+				"id": {"type": "string"},
+				"score": {"type": "array", "items": {"type": "integer"}, "minItems": 2, "maxItems": 2}
+			},
+			"required": ["kind", "id", "score"],
+			"additionalProperties": false
+		}`)
+	})
+
+	t.Run("required pointers are not nullable", func(t *testing.T) {
+		type Item struct {
+			Note     *string `json:"note" railguard:"required"`
+			Comment  *string `json:"comment"`
+			Quantity *int    `json:"quantity,string" railguard:"required"`
+		}
+		schema, _ := railguard.NewSchema(&Item{})
+		assertJSONSchema(t, schema, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"note": {"type": "string"},
+				"comment": {"type": ["string", "null"]},
+				"quantity": {"type": "string"}
+			},
+			"required": ["note", "quantity"],
+			"additionalProperties": false
+		}`)
+
+		// The schema matches what Unmarshal accepts
+		if _, err := schema.Unmarshal([]byte(`{"note": null, "quantity": "1"}`)); err == nil {
+			t.Error("expected null to be rejected for a required pointer")
+		}
+	})
+
+	t.Run("recursive types", func(t *testing.T) {
+		type Node struct {
+			Name     string  `json:"name"`
+			Children []*Node `json:"children,omitempty"`
+		}
+		type Tree struct {
+			Root *Node `json:"root"`
+			Size int   `json:"size"`
+		}
+
+		schema, _ := railguard.NewSchema(&Tree{})
+		assertJSONSchema(t, schema, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"root": {"anyOf": [{"$ref": "#/$defs/Node"}, {"type": "null"}]},
+				"size": {"type": "integer"}
+			},
+			"required": ["size"],
+			"additionalProperties": false,
+			"$defs": {
+				"Node": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"children": {"type": "array", "items": {"anyOf": [{"$ref": "#/$defs/Node"}, {"type": "null"}]}}
+					},
+					"required": ["name"],
+					"additionalProperties": false
+				}
+			}
+		}`)
+
+		schema, _ = railguard.NewSchema(&Node{})
+		assertJSONSchema(t, schema.WithStrict(false), `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"children": {"type": "array", "items": {"anyOf": [{"$ref": "#"}, {"type": "null"}]}}
+			},
+			"required": ["name"]
+		}`)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		type Bad struct {
+			Callback func() `json:"callback"`
+		}
+		schema, _ := railguard.NewSchema(&Bad{})
+		if _, err := schema.JSONSchema(); !errors.Is(err, railguard.ErrInvalidSchema) {
+			t.Errorf("expected ErrInvalidSchema, got %v", err)
+		}
+	})
+
+	t.Run("valid output matches schema", func(t *testing.T) {
+		// Every property of a decoded value appears in the schema
+		schema, _ := railguard.NewSchema(&invoice{})
+		doc, _ := schema.JSONSchema()
+
+		var parsed struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(doc, &parsed); err != nil {
+			t.Fatalf("schema is not valid JSON: %v", err)
+		}
+		encoded, _ := json.Marshal(invoice{Tags: map[string]string{"a": "b"}, Raw: json.RawMessage(`1`), Data: []byte("x")})
+		var fields map[string]json.RawMessage
+		_ = json.Unmarshal(encoded, &fields)
+		for name := range fields {
+			if _, ok := parsed.Properties[name]; !ok {
+				t.Errorf("encoded field %q missing from schema", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,275 @@
+package railguard
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// requestIDKey is the context key for request IDs.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+// Loggers configured with WithLogger include it in every record.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// LogOption configures the logging set up by WithLogger.
+type LogOption func(*logConfig)
+
+// logConfig holds the settings for slogObserver.
+type logConfig struct {
+	bodyLimit int
+	redact    func(string) string
+	requestID func(context.Context) string
+}
+
+// LogBodyLimit truncates logged prompt and output bodies to at most n bytes.
+// A limit of 0 (the default) logs bodies in full.
+func LogBodyLimit(n int) LogOption {
+	return func(c *logConfig) {
+		c.bodyLimit = n
+	}
+}
+
+// LogRedact sets a function applied to prompt and output bodies and error
+// messages before they are logged, e.g. to mask customer data. Redaction runs
+// before truncation, and only when debug records are enabled.
+func LogRedact(redact func(string) string) LogOption {
+	return func(c *logConfig) {
+		c.redact = redact
+	}
+}
+
+// LogRequestID sets the function used to read a request ID from the context.
+// By default, the ID stored with WithRequestID is used.
+func LogRequestID(fn func(ctx context.Context) string) LogOption {
+	return func(c *logConfig) {
+		c.requestID = fn
+	}
+}
+
+// slogObserver is an Observer that emits structured log records.
+// Prompt and output bodies are only included in debug-level records.
+type slogObserver struct {
+	logger *slog.Logger
+	config logConfig
+}
+
+// newSlogObserver creates an observer that logs to logger.
+func newSlogObserver(logger *slog.Logger, opts ...LogOption) *slogObserver {
+	config := logConfig{
+		requestID: func(ctx context.Context) string {
+			id, _ := RequestIDFromContext(ctx)
+			return id
+		},
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &slogObserver{logger: logger, config: config}
+}
+
+// log emits a record with the request ID prepended to attrs.
+func (o *slogObserver) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !o.logger.Enabled(ctx, level) {
+		return
+	}
+	if id := o.config.requestID(ctx); id != "" {
+		attrs = append([]slog.Attr{slog.String("request_id", id)}, attrs...)
+	}
+	o.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// debug reports whether debug records are logged, so that bodies are only
+// prepared, and LogRedact only called, when they are.
+func (o *slogObserver) debug(ctx context.Context) bool {
+	return o.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// failure logs a failed stage or run at level with the kind of err. Error
+// messages may quote prompts, outputs, or API responses, so the message is
+// only logged in a separate debug record, prepared like a body.
+func (o *slogObserver) failure(ctx context.Context, level slog.Level, msg string, err error, attrs ...slog.Attr) {
+	o.log(ctx, level, msg, append(attrs, errorAttrs(err)...)...)
+	if o.debug(ctx) {
+		o.log(ctx, slog.LevelDebug, msg+" details", append(attrs, slog.String("error", o.body(err.Error())))...)
+	}
+}
+
+// body prepares a prompt or output body for logging.
+func (o *slogObserver) body(s string) string {
+	if o.config.redact != nil {
+		s = o.config.redact(s)
+	}
+	if o.config.bodyLimit > 0 && len(s) > o.config.bodyLimit {
+		// Cut at a rune boundary so the logged body stays valid UTF-8
+		end := o.config.bodyLimit
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		s = s[:end] + "...(truncated)"
+	}
+	return s
+}
+
+// stage logs a pipeline stage result: debug on success, warn on failure.
+func (o *slogObserver) stage(ctx context.Context, msg string, err error, attrs ...slog.Attr) {
+	if err == nil {
+		o.log(ctx, slog.LevelDebug, msg+" passed", attrs...)
+		return
+	}
+	o.failure(ctx, slog.LevelWarn, msg+" failed", err, attrs...)
+}
+
+func (o *slogObserver) OnRunStart(ctx context.Context, e RunStartEvent) {
+	if !o.debug(ctx) {
+		return
+	}
+	o.log(ctx, slog.LevelDebug, "railguard run started",
+		slog.Int("messages", len(e.Messages)),
+		slog.String("prompt", o.body(flattenMessages(e.Messages))),
+	)
+}
+
+func (o *slogObserver) OnRunEnd(ctx context.Context, e RunEndEvent) {
+	attrs := []slog.Attr{
+		slog.Int("attempts", e.Attempts),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.Err != nil {
+		o.failure(ctx, slog.LevelError, "railguard run failed", e.Err, attrs...)
+		return
+	}
+	o.log(ctx, slog.LevelInfo, "railguard run completed", attrs...)
+}
+
+func (o *slogObserver) OnDetector(ctx context.Context, e DetectorEvent) {
+	o.stage(ctx, "railguard detector", e.Err,
+		slog.String("detector", e.Detector),
+		slog.Duration("duration", e.Duration),
+	)
+}
+
+func (o *slogObserver) OnGenerationStart(ctx context.Context, e GenerationStartEvent) {
+	o.log(ctx, slog.LevelDebug, "railguard generation started",
+		slog.Int("attempt", e.Attempt),
+	)
+}
+
+func (o *slogObserver) OnGenerationEnd(ctx context.Context, e GenerationEndEvent) {
+	attrs := []slog.Attr{
+		slog.Int("attempt", e.Attempt),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.Err != nil {
+		o.failure(ctx, slog.LevelWarn, "railguard generation failed", &GenerationError{Err: e.Err}, attrs...)
+		return
+	}
+	if !o.debug(ctx) {
+		return
+	}
+	o.log(ctx, slog.LevelDebug, "railguard generation completed",
+		append(attrs, slog.String("output", o.body(e.Output)))...)
+}
+
+func (o *slogObserver) OnTransformer(ctx context.Context, e TransformerEvent) {
+	o.stage(ctx, "railguard transformer", e.Err,
+		slog.Int("attempt", e.Attempt),
+		slog.String("transformer", e.Transformer),
+		slog.Duration("duration", e.Duration),
+	)
+}
+
+func (o *slogObserver) OnValidator(ctx context.Context, e ValidatorEvent) {
+	o.stage(ctx, "railguard validator", e.Err,
+		slog.Int("attempt", e.Attempt),
+		slog.String("validator", e.Validator),
+		slog.Duration("duration", e.Duration),
+	)
+}
+
+func (o *slogObserver) OnSchema(ctx context.Context, e SchemaEvent) {
+	attrs := []slog.Attr{
+		slog.Int("attempt", e.Attempt),
+		slog.Duration("duration", e.Duration),
+	}
+	if len(e.JSONFixes) > 0 {
+		attrs = append(attrs, slog.Any("json_fixes", e.JSONFixes))
+	}
+	o.stage(ctx, "railguard schema", e.Err, attrs...)
+}
+
+func (o *slogObserver) OnBackoff(ctx context.Context, e BackoffEvent) {
+	o.log(ctx, slog.LevelDebug, "railguard backing off",
+		slog.Int("attempt", e.Attempt),
+		slog.Duration("delay", e.Delay),
+	)
+}
+
+func (o *slogObserver) OnRetry(ctx context.Context, e RetryEvent) {
+	o.failure(ctx, slog.LevelInfo, "railguard attempt failed", e.Err,
+		slog.Int("attempt", e.Attempt),
+		slog.Bool("retry", e.Retry),
+	)
+}
+
+// errorAttrs returns the attributes describing err without its message: its
+// kind and, for schema errors, the kind and path of each issue. Paths of
+// unknown fields are left out, since they are keys chosen by the model.
+func errorAttrs(err error) []slog.Attr {
+	attrs := []slog.Attr{slog.String("error_kind", errorKind(err))}
+
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) && len(schemaErr.Issues) > 0 {
+		issues := make([]string, len(schemaErr.Issues))
+		for i, issue := range schemaErr.Issues {
+			issues[i] = string(issue.Kind)
+			if issue.Path != "" && issue.Kind != IssueUnknownField {
+				issues[i] += " " + issue.Path
+			}
+		}
+		attrs = append(attrs, slog.Any("schema_issues", issues))
+	}
+	return attrs
+}
+
+// errorKind returns the name of the railguard error type of err,
+// e.g. "DetectionError", or "error" for other errors.
+func errorKind(err error) string {
+	switch err.(type) {
+	case *DetectionError:
+		return "DetectionError"
+	case *TransformError:
+		return "TransformError"
+	case *ValidationError:
+		return "ValidationError"
+	case *SchemaError:
+		return "SchemaError"
+	case *GenerationError:
+		return "GenerationError"
+	case *MaxRetriesError:
+		return "MaxRetriesError"
+	case *RetryAbortedError:
+		return "RetryAbortedError"
+	case *MultiError:
+		return "MultiError"
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DeadlineExceeded"
+	default:
+		return "error"
+	}
+}
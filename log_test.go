@@ -0,0 +1,332 @@
+package railguard_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// logRecords parses JSON log output into one map per record.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// findRecord returns the first record with the given message.
+func findRecord(records []map[string]interface{}, msg string) map[string]interface{} {
+	for _, r := range records {
+		if r["msg"] == msg {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("logs structured pipeline events", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		attempts := 0
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			attempts++
+			if attempts == 1 {
+				return "bad", nil
+			}
+			return "good", nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithDetectors(&mockDetector{name: "keywords"}),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if output == "bad" {
+					return errors.New("rejected")
+				}
+				return nil
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+			railguard.WithLogger(logger),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		ctx := railguard.WithRequestID(context.Background(), "req-123")
+		if _, err := g.Run(ctx, "secret prompt"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		records := logRecords(t, &buf)
+		for _, r := range records {
+			if r["request_id"] != "req-123" {
+				t.Errorf("expected request ID in every record, got %v", r)
+			}
+		}
+
+		detector := findRecord(records, "railguard detector passed")
+		if detector == nil || detector["detector"] != "keywords" {
+			t.Errorf("expected detector record with name, got %v", detector)
+		}
+
+		validator := findRecord(records, "railguard validator failed")
+		if validator == nil {
+			t.Fatal("expected validator failure record")
+		}
+		if validator["validator"] != "custom" || validator["error_kind"] != "ValidationError" || validator["attempt"] != float64(1) {
+			t.Errorf("unexpected validator record: %v", validator)
+		}
+		if validator["level"] != "WARN" {
+			t.Errorf("expected validator failure at warn level, got %v", validator["level"])
+		}
+
+		end := findRecord(records, "railguard run completed")
+		if end == nil || end["attempts"] != float64(2) {
+			t.Errorf("unexpected run end record: %v", end)
+		}
+
+		start := findRecord(records, "railguard run started")
+		if start == nil || start["prompt"] != "secret prompt" {
+			t.Errorf("expected prompt body at debug level, got %v", start)
+		}
+	})
+
+	t.Run("bodies are not logged above debug level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithLogger(logger),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "secret prompt"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		out := buf.String()
+		if strings.Contains(out, "secret prompt") || strings.Contains(out, "response") {
+			t.Errorf("bodies should not be logged at info level: %s", out)
+		}
+		if !strings.Contains(out, "railguard run completed") {
+			t.Errorf("expected run completion record: %s", out)
+		}
+	})
+
+	t.Run("constraint violations do not quote the output", func(t *testing.T) {
+		type Response struct {
+			Status string `json:"status" railguard:"enum=paid|void"`
+			Code   string `json:"code" railguard:"pattern=^[A-Z]+$"`
+		}
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+		g, err := railguard.New(
+			railguard.WithClient(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				return `{"status": "secret status", "code": "secret code"}`, nil
+			})),
+			railguard.WithSchema(&Response{}),
+			railguard.WithMaxRetries(1),
+			railguard.WithLogger(logger),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err == nil {
+			t.Fatal("expected constraint error")
+		}
+
+		out := buf.String()
+		if strings.Contains(out, "secret") {
+			t.Errorf("output values should not be logged at info level: %s", out)
+		}
+		if !strings.Contains(out, `"schema_issues":["constraint /status","constraint /code"]`) {
+			t.Errorf("expected violation kinds and paths in the log: %s", out)
+		}
+	})
+
+	t.Run("error messages are logged only at debug level", func(t *testing.T) {
+		for _, level := range []slog.Level{slog.LevelInfo, slog.LevelDebug} {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+			g, err := railguard.New(
+				railguard.WithClient(&mockClient{}),
+				railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+					return errors.New("rejected secret output")
+				})),
+				railguard.WithMaxRetries(1),
+				railguard.WithLogger(logger),
+			)
+			if err != nil {
+				t.Fatalf("failed to create guard: %v", err)
+			}
+
+			if _, err := g.Run(context.Background(), "test"); err == nil {
+				t.Fatal("expected validation error")
+			}
+
+			records := logRecords(t, &buf)
+			failed := findRecord(records, "railguard validator failed")
+			if failed == nil || failed["error_kind"] != "ValidationError" || failed["error"] != nil {
+				t.Errorf("expected validator failure without message at %v, got %v", level, failed)
+			}
+			details := findRecord(records, "railguard validator failed details")
+			if level == slog.LevelDebug {
+				if details == nil || !strings.Contains(details["error"].(string), "rejected secret output") {
+					t.Errorf("expected error message at debug level, got %v", details)
+				}
+			} else if strings.Contains(buf.String(), "secret") {
+				t.Errorf("error messages should not be logged at info level: %s", buf.String())
+			}
+		}
+	})
+
+	t.Run("redact is not called above debug level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+		calls := 0
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				return errors.New("rejected")
+			})),
+			railguard.WithMaxRetries(1),
+			railguard.WithLogger(logger, railguard.LogRedact(func(s string) string {
+				calls++
+				return s
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		g.Run(context.Background(), "test")
+		if calls != 0 {
+			t.Errorf("expected redact not to be called at info level, got %d calls", calls)
+		}
+	})
+
+	t.Run("redacts and truncates bodies", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithLogger(logger,
+				railguard.LogRedact(func(s string) string {
+					return strings.ReplaceAll(s, "4111-1111", "****")
+				}),
+				railguard.LogBodyLimit(12),
+			),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "card 4111-1111 please charge it"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		start := findRecord(logRecords(t, &buf), "railguard run started")
+		if start == nil {
+			t.Fatal("expected run start record")
+		}
+		if start["prompt"] != "card **** pl...(truncated)" {
+			t.Errorf("unexpected logged prompt: %v", start["prompt"])
+		}
+	})
+
+	t.Run("reports detection error kind", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithDetectors(railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+				return errors.New("blocked")
+			})),
+			railguard.WithLogger(logger),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, _ = g.Run(context.Background(), "test")
+
+		end := findRecord(logRecords(t, &buf), "railguard run failed")
+		if end == nil || end["error_kind"] != "DetectionError" || end["level"] != "ERROR" {
+			t.Errorf("unexpected run failure record: %v", end)
+		}
+	})
+
+	t.Run("custom request ID extractor", func(t *testing.T) {
+		type key struct{}
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithLogger(logger, railguard.LogRequestID(func(ctx context.Context) string {
+				id, _ := ctx.Value(key{}).(string)
+				return id
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		ctx := context.WithValue(context.Background(), key{}, "trace-9")
+		if _, err := g.Run(ctx, "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		end := findRecord(logRecords(t, &buf), "railguard run completed")
+		if end == nil || end["request_id"] != "trace-9" {
+			t.Errorf("expected custom request ID, got %v", end)
+		}
+	})
+
+	t.Run("nil logger", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithLogger(nil),
+		)
+		if !errors.Is(err, railguard.ErrNilLogger) {
+			t.Errorf("expected ErrNilLogger, got %v", err)
+		}
+	})
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	if _, ok := railguard.RequestIDFromContext(context.Background()); ok {
+		t.Error("expected no request ID in empty context")
+	}
+
+	ctx := railguard.WithRequestID(context.Background(), "abc")
+	if id, ok := railguard.RequestIDFromContext(ctx); !ok || id != "abc" {
+		t.Errorf("expected request ID 'abc', got %q", id)
+	}
+}
@@ -0,0 +1,65 @@
+package railguard
+
+import "strings"
+
+// Role identifies the author of a chat message.
+type Role string
+
+// Standard chat message roles.
+const (
+	// RoleSystem marks instructions from the application, such as a system prompt.
+	RoleSystem Role = "system"
+
+	// RoleUser marks untrusted input from the end user.
+	RoleUser Role = "user"
+
+	// RoleAssistant marks previous responses from the LLM.
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single turn in a chat conversation.
+type Message struct {
+	// Role is the author of the message.
+	Role Role
+
+	// Content is the text of the message.
+	Content string
+}
+
+// flattenMessages renders a conversation as a single prompt.
+// A conversation consisting of one user message is rendered as its content,
+// so prompt-based clients see exactly what was passed to Guard.Run.
+func flattenMessages(messages []Message) string {
+	if len(messages) == 1 && messages[0].Role == RoleUser {
+		return messages[0].Content
+	}
+
+	parts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		parts = append(parts, roleLabel(m.Role)+": "+m.Content)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// roleLabel returns the capitalized role name used when flattening messages.
+func roleLabel(role Role) string {
+	if role == "" {
+		return ""
+	}
+	return strings.ToUpper(string(role[:1])) + string(role[1:])
+}
+
+// detectionInput joins the content of all messages with one of the given
+// roles. This is the text detectors see for a conversation.
+func detectionInput(messages []Message, roles []Role) string {
+	parts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		for _, r := range roles {
+			if m.Role == r {
+				parts = append(parts, m.Content)
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
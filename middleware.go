@@ -0,0 +1,66 @@
+package railguard
+
+// ClientMiddleware wraps a Client with additional behavior, such as logging,
+// authentication, rate limiting, or fault injection. Middlewares that should
+// keep native chat support must return a Client that also implements
+// ChatClient; see the middleware package for helpers. New rejects a
+// middleware that does not when the Guard's client supports chat.
+type ClientMiddleware func(Client) Client
+
+// DetectorMiddleware wraps a Detector with additional behavior.
+// The returned detector should keep the wrapped detector's Name.
+type DetectorMiddleware func(Detector) Detector
+
+// ValidatorMiddleware wraps a Validator with additional behavior.
+// The returned validator should keep the wrapped validator's Name.
+type ValidatorMiddleware func(Validator) Validator
+
+// Chain composes middlewares into a single middleware. The first middleware
+// is the outermost: Chain(a, b)(x) is a(b(x)), so a sees every call first.
+//
+// Example:
+//
+//	mw := railguard.Chain(middleware.Logging(logger), middleware.ConcurrencyLimit(4))
+//	client = mw(client)
+func Chain[M ~func(T) T, T any](middlewares ...M) M {
+	return func(next T) T {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// applyMiddleware wraps the client, detectors, and validators with the
+// configured middlewares. It is called once by New after all options ran,
+// so the order of options does not matter. It returns ErrChatMiddleware if
+// a client middleware would flatten the conversation for a chat client.
+func (g *Guard) applyMiddleware() error {
+	if len(g.clientMiddleware) > 0 {
+		_, flat := g.chat.(*flatChatClient)
+		client := g.client
+		for i := len(g.clientMiddleware) - 1; i >= 0; i-- {
+			client = g.clientMiddleware[i](client)
+			if _, ok := client.(ChatClient); !ok && !flat {
+				return ErrChatMiddleware
+			}
+		}
+		g.client = client
+		g.chat = AsChatClient(client)
+	}
+
+	if len(g.detectorMiddleware) > 0 {
+		wrap := Chain(g.detectorMiddleware...)
+		for i, detector := range g.detectors {
+			g.detectors[i] = wrap(detector)
+		}
+	}
+
+	if len(g.validatorMiddleware) > 0 {
+		wrap := Chain(g.validatorMiddleware...)
+		for i, validator := range g.validators {
+			g.validators[i] = wrap(validator)
+		}
+	}
+	return nil
+}
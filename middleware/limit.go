@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// ConcurrencyLimit returns a ClientMiddleware that allows at most n
+// generation calls in flight. Further calls wait for a free slot or until
+// their context is done. All clients wrapped by the same returned middleware
+// share the limit. A stream holds its slot until it ends. Values of n below 1
+// are treated as 1.
+func ConcurrencyLimit(n int) railguard.ClientMiddleware {
+	slots := make(chan struct{}, max(n, 1))
+
+	return Around(func(ctx context.Context, next Next) (string, error) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		defer func() { <-slots }()
+
+		return next(ctx)
+	})
+}
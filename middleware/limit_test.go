@@ -0,0 +1,119 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/middleware"
+)
+
+// limitStreamClient is a Client that streams with the function itself.
+type limitStreamClient func(ctx context.Context, prompt string) (<-chan railguard.Chunk, error)
+
+func (f limitStreamClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (f limitStreamClient) GenerateStream(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+	return f(ctx, prompt)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	t.Run("limits calls in flight", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return "ok", nil
+		})
+
+		limited := middleware.ConcurrencyLimit(2)(client)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = limited.Generate(context.Background(), "test")
+			}()
+		}
+		wg.Wait()
+
+		if peak.Load() > 2 {
+			t.Errorf("expected at most 2 calls in flight, got %d", peak.Load())
+		}
+	})
+
+	t.Run("limits streams until they end", func(t *testing.T) {
+		release := make(chan struct{})
+		var started atomic.Int32
+		client := limitStreamClient(func(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+			started.Add(1)
+			chunks := make(chan railguard.Chunk)
+			go func() {
+				defer close(chunks)
+				<-release
+				chunks <- railguard.Chunk{Text: "ok"}
+			}()
+			return chunks, nil
+		})
+
+		limited := middleware.ConcurrencyLimit(1)(client).(railguard.StreamingClient)
+		first, err := limited.GenerateStream(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := limited.GenerateStream(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected second stream to wait for the slot, got %v", err)
+		}
+		if started.Load() != 1 {
+			t.Errorf("expected 1 stream started, got %d", started.Load())
+		}
+
+		close(release)
+		for range first {
+			// Drain the first stream, which frees the slot
+		}
+		second, err := limited.GenerateStream(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("expected slot to be free after the stream ended, got %v", err)
+		}
+		for range second {
+			// Drain the second stream
+		}
+	})
+
+	t.Run("waiting call honors context", func(t *testing.T) {
+		release := make(chan struct{})
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			<-release
+			return "ok", nil
+		})
+
+		limited := middleware.ConcurrencyLimit(1)(client)
+		go func() { _, _ = limited.Generate(context.Background(), "test") }()
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := limited.Generate(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		close(release)
+	})
+}
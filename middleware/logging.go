@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// Logging returns a ClientMiddleware that logs every generation call to
+// logger: at debug level on success and at warn level on failure, with the
+// call's duration and error. Prompts and outputs are not logged.
+func Logging(logger *slog.Logger) railguard.ClientMiddleware {
+	return Around(func(ctx context.Context, next Next) (string, error) {
+		start := time.Now()
+		output, err := next(ctx)
+		logResult(ctx, logger, "railguard client call", err,
+			slog.Duration("duration", time.Since(start)),
+		)
+		return output, err
+	})
+}
+
+// DetectorLogging returns a DetectorMiddleware that logs every Detect call
+// to logger with the detector's name, duration, and error.
+func DetectorLogging(logger *slog.Logger) railguard.DetectorMiddleware {
+	return AroundDetector(func(ctx context.Context, name string, next NextCheck) error {
+		start := time.Now()
+		err := next(ctx)
+		logResult(ctx, logger, "railguard detector call", err,
+			slog.String("detector", name),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return err
+	})
+}
+
+// ValidatorLogging returns a ValidatorMiddleware that logs every Validate
+// call to logger with the validator's name, duration, and error.
+func ValidatorLogging(logger *slog.Logger) railguard.ValidatorMiddleware {
+	return AroundValidator(func(ctx context.Context, name string, next NextCheck) error {
+		start := time.Now()
+		err := next(ctx)
+		logResult(ctx, logger, "railguard validator call", err,
+			slog.String("validator", name),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return err
+	})
+}
+
+// logResult logs a call at debug level on success and warn level on failure.
+func logResult(ctx context.Context, logger *slog.Logger, msg string, err error, attrs ...slog.Attr) {
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.LogAttrs(ctx, slog.LevelWarn, msg+" failed", attrs...)
+		return
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, msg+" succeeded", attrs...)
+}
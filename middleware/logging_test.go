@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/middleware"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	t.Run("client", func(t *testing.T) {
+		buf.Reset()
+		client := middleware.Logging(logger)(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "", errors.New("overloaded")
+		}))
+
+		_, _ = client.Generate(context.Background(), "secret prompt")
+		out := buf.String()
+		if !strings.Contains(out, "railguard client call failed") || !strings.Contains(out, "level=WARN") {
+			t.Errorf("expected warn record for failed call, got %q", out)
+		}
+		if strings.Contains(out, "secret prompt") {
+			t.Errorf("expected prompt not to be logged, got %q", out)
+		}
+	})
+
+	t.Run("detector", func(t *testing.T) {
+		buf.Reset()
+		detector := middleware.DetectorLogging(logger)(railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+			return nil
+		}))
+
+		_ = detector.Detect(context.Background(), "test")
+		out := buf.String()
+		if !strings.Contains(out, "railguard detector call succeeded") || !strings.Contains(out, "detector=custom") {
+			t.Errorf("unexpected log output: %q", out)
+		}
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		buf.Reset()
+		validator := middleware.ValidatorLogging(logger)(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+			return errors.New("invalid")
+		}))
+
+		_ = validator.Validate(context.Background(), "test")
+		out := buf.String()
+		if !strings.Contains(out, "railguard validator call failed") || !strings.Contains(out, "error=invalid") {
+			t.Errorf("unexpected log output: %q", out)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+// Package middleware provides helpers and built-in middlewares for wrapping
+// railguard clients, detectors, and validators.
+//
+// Middlewares compose with railguard.Chain and are installed on a Guard with
+// railguard.WithClientMiddleware, railguard.WithDetectorMiddleware, and
+// railguard.WithValidatorMiddleware.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// Next performs the wrapped generation call with the given context.
+// Middlewares may pass a derived context.
+type Next func(ctx context.Context) (string, error)
+
+// NextCheck runs the wrapped detector or validator with the given context.
+// Middlewares may pass a derived context.
+type NextCheck func(ctx context.Context) error
+
+// Around returns a ClientMiddleware that runs fn around every Generate and
+// Chat call. fn must call next to perform the wrapped call, and may inspect
+// or replace its result. The wrapped client keeps native chat support, and a
+// wrapped StreamingClient stays a StreamingClient: fn also runs around every
+// GenerateStream call, where next starts the stream and returns the streamed
+// text once the stream has ended.
+func Around(fn func(ctx context.Context, next Next) (string, error)) railguard.ClientMiddleware {
+	return func(next railguard.Client) railguard.Client {
+		wrapped := &aroundClient{
+			next: next,
+			chat: railguard.AsChatClient(next),
+			fn:   fn,
+		}
+		if sc, ok := next.(railguard.StreamingClient); ok {
+			return &aroundStreamingClient{aroundClient: wrapped, stream: sc}
+		}
+		if sc, ok := wrapped.chat.(railguard.StreamingClient); ok {
+			return &aroundStreamingClient{aroundClient: wrapped, stream: sc}
+		}
+		return wrapped
+	}
+}
+
+// aroundClient implements Client and ChatClient by running fn around the wrapped client.
+type aroundClient struct {
+	next railguard.Client
+	chat railguard.ChatClient
+	fn   func(ctx context.Context, next Next) (string, error)
+}
+
+// Generate implements the railguard.Client interface.
+func (c *aroundClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.fn(ctx, func(ctx context.Context) (string, error) {
+		return c.next.Generate(ctx, prompt)
+	})
+}
+
+// Chat implements the railguard.ChatClient interface.
+func (c *aroundClient) Chat(ctx context.Context, messages []railguard.Message) (string, error) {
+	return c.fn(ctx, func(ctx context.Context) (string, error) {
+		return c.chat.Chat(ctx, messages)
+	})
+}
+
+// aroundStreamingClient is an aroundClient for a wrapped StreamingClient.
+type aroundStreamingClient struct {
+	*aroundClient
+	stream railguard.StreamingClient
+}
+
+// GenerateStream implements the railguard.StreamingClient interface. fn runs
+// in the background for the whole stream, so that e.g. ConcurrencyLimit holds
+// its slot until the stream ends. Chunks are passed on as they arrive; an
+// error fn returns after the stream is reported as a final chunk, and an
+// output fn returns without calling next is sent as a single chunk.
+func (c *aroundStreamingClient) GenerateStream(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+	out := make(chan railguard.Chunk)
+	started := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+
+		called, streaming := false, false
+		var streamErr error
+		output, err := c.fn(ctx, func(ctx context.Context) (string, error) {
+			if called {
+				return "", errors.New("middleware: stream started twice")
+			}
+			called = true
+			chunks, err := c.stream.GenerateStream(ctx, prompt)
+			started <- err
+			if err != nil {
+				return "", err
+			}
+			streaming = true
+			var text string
+			text, streamErr = forward(ctx, chunks, out)
+			return text, streamErr
+		})
+
+		switch {
+		case !called:
+			// fn answered without starting the stream
+			started <- err
+			if err == nil {
+				send(ctx, out, railguard.Chunk{Text: output})
+			}
+		case streaming && err != nil && err != streamErr:
+			send(ctx, out, railguard.Chunk{Err: err})
+		}
+	}()
+
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// forward passes chunks on to out until the stream ends, returning the
+// streamed text and the error of the final chunk, if any. If ctx is done,
+// the remaining chunks are discarded so that the producer is not blocked.
+func forward(ctx context.Context, chunks <-chan railguard.Chunk, out chan<- railguard.Chunk) (string, error) {
+	var sb strings.Builder
+	for chunk := range chunks {
+		if !send(ctx, out, chunk) {
+			for range chunks {
+				// Discard remaining chunks
+			}
+			return sb.String(), ctx.Err()
+		}
+		if chunk.Err != nil {
+			return sb.String(), chunk.Err
+		}
+		sb.WriteString(chunk.Text)
+	}
+	return sb.String(), nil
+}
+
+// send delivers chunk to out, reporting false if ctx is done first.
+func send(ctx context.Context, out chan<- railguard.Chunk, chunk railguard.Chunk) bool {
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// AroundDetector returns a DetectorMiddleware that runs fn around every
+// Detect call. fn receives the detector's name and must call next to run
+// the wrapped detector. The wrapped detector keeps its name.
+func AroundDetector(fn func(ctx context.Context, name string, next NextCheck) error) railguard.DetectorMiddleware {
+	return func(next railguard.Detector) railguard.Detector {
+		return &aroundDetector{next: next, fn: fn}
+	}
+}
+
+// aroundDetector implements Detector by running fn around the wrapped detector.
+type aroundDetector struct {
+	next railguard.Detector
+	fn   func(ctx context.Context, name string, next NextCheck) error
+}
+
+// Detect implements the railguard.Detector interface.
+func (d *aroundDetector) Detect(ctx context.Context, prompt string) error {
+	return d.fn(ctx, d.next.Name(), func(ctx context.Context) error {
+		return d.next.Detect(ctx, prompt)
+	})
+}
+
+// Name returns the wrapped detector's name.
+func (d *aroundDetector) Name() string {
+	return d.next.Name()
+}
+
+// AroundValidator returns a ValidatorMiddleware that runs fn around every
+// Validate call. fn receives the validator's name and must call next to run
+// the wrapped validator. The wrapped validator keeps its name, and a wrapped
+// StreamValidator stays a StreamValidator; its ValidateStream calls are
+// passed through without running fn.
+func AroundValidator(fn func(ctx context.Context, name string, next NextCheck) error) railguard.ValidatorMiddleware {
+	return func(next railguard.Validator) railguard.Validator {
+		wrapped := &aroundValidator{next: next, fn: fn}
+		if sv, ok := next.(railguard.StreamValidator); ok {
+			return &aroundStreamValidator{aroundValidator: wrapped, stream: sv}
+		}
+		return wrapped
+	}
+}
+
+// aroundValidator implements Validator by running fn around the wrapped validator.
+type aroundValidator struct {
+	next railguard.Validator
+	fn   func(ctx context.Context, name string, next NextCheck) error
+}
+
+// Validate implements the railguard.Validator interface.
+func (v *aroundValidator) Validate(ctx context.Context, output string) error {
+	return v.fn(ctx, v.next.Name(), func(ctx context.Context) error {
+		return v.next.Validate(ctx, output)
+	})
+}
+
+// Name returns the wrapped validator's name.
+func (v *aroundValidator) Name() string {
+	return v.next.Name()
+}
+
+// aroundStreamValidator is an aroundValidator for a wrapped StreamValidator.
+type aroundStreamValidator struct {
+	*aroundValidator
+	stream railguard.StreamValidator
+}
+
+// ValidateStream implements the railguard.StreamValidator interface.
+func (v *aroundStreamValidator) ValidateStream(ctx context.Context, partial string) error {
+	return v.stream.ValidateStream(ctx, partial)
+}
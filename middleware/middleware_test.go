@@ -0,0 +1,213 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/middleware"
+	"github.com/RasmusHilmar1/railguard/validators"
+)
+
+// streamClient is a Client that also streams its output as a single chunk.
+type streamClient struct{}
+
+func (streamClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return "generated", nil
+}
+
+func (streamClient) GenerateStream(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+	chunks := make(chan railguard.Chunk, 1)
+	chunks <- railguard.Chunk{Text: "streamed"}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestAround(t *testing.T) {
+	t.Run("wraps generate", func(t *testing.T) {
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "output for " + prompt, nil
+		})
+		mw := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			output, err := next(ctx)
+			return "[" + output + "]", err
+		})
+
+		out, err := mw(client).Generate(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out != "[output for test]" {
+			t.Errorf("unexpected output: %q", out)
+		}
+	})
+
+	t.Run("keeps chat support", func(t *testing.T) {
+		var got []railguard.Message
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			got = messages
+			return "ok", nil
+		})
+		calls := 0
+		mw := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			calls++
+			return next(ctx)
+		})
+
+		wrapped := railguard.AsChatClient(mw(railguard.AsClient(chat)))
+		messages := []railguard.Message{
+			{Role: railguard.RoleSystem, Content: "be brief"},
+			{Role: railguard.RoleUser, Content: "hi"},
+		}
+		if _, err := wrapped.Chat(context.Background(), messages); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 || calls != 1 {
+			t.Errorf("expected one wrapped chat call with 2 messages, got %d calls and %+v", calls, got)
+		}
+	})
+
+	t.Run("keeps streaming support", func(t *testing.T) {
+		calls := 0
+		mw := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			calls++
+			return next(ctx)
+		})
+
+		wrapped := mw(streamClient{})
+		sc, ok := wrapped.(railguard.StreamingClient)
+		if !ok {
+			t.Fatal("expected wrapped client to implement StreamingClient")
+		}
+		chunks, err := sc.GenerateStream(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []railguard.Chunk
+		for chunk := range chunks {
+			got = append(got, chunk)
+		}
+		if len(got) != 1 || got[0].Text != "streamed" {
+			t.Errorf("expected streamed chunk, got %+v", got)
+		}
+		if calls != 1 {
+			t.Errorf("expected middleware to run around the stream, got %d calls", calls)
+		}
+
+		plain := mw(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "ok", nil
+		}))
+		if _, ok := plain.(railguard.StreamingClient); ok {
+			t.Error("expected plain client to stay a plain client")
+		}
+	})
+
+	t.Run("stream sees middleware errors", func(t *testing.T) {
+		injected := errors.New("injected fault")
+		reject := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			return "", injected
+		})
+		sc := reject(streamClient{}).(railguard.StreamingClient)
+		if _, err := sc.GenerateStream(context.Background(), "test"); !errors.Is(err, injected) {
+			t.Errorf("expected injected error before the stream, got %v", err)
+		}
+
+		fail := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			if _, err := next(ctx); err != nil {
+				return "", err
+			}
+			return "", injected
+		})
+		chunks, err := fail(streamClient{}).(railguard.StreamingClient).GenerateStream(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var last railguard.Chunk
+		for chunk := range chunks {
+			last = chunk
+		}
+		if !errors.Is(last.Err, injected) {
+			t.Errorf("expected injected error as the final chunk, got %+v", last)
+		}
+	})
+
+	t.Run("can short-circuit", func(t *testing.T) {
+		injected := errors.New("injected fault")
+		called := false
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			called = true
+			return "ok", nil
+		})
+		mw := middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+			return "", injected
+		})
+
+		if _, err := mw(client).Generate(context.Background(), "test"); !errors.Is(err, injected) {
+			t.Errorf("expected injected error, got %v", err)
+		}
+		if called {
+			t.Error("expected client not to be called")
+		}
+	})
+}
+
+func TestAroundDetector(t *testing.T) {
+	blocked := errors.New("blocked")
+	detector := railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+		return blocked
+	})
+
+	var seen string
+	mw := middleware.AroundDetector(func(ctx context.Context, name string, next middleware.NextCheck) error {
+		seen = name
+		return next(ctx)
+	})
+
+	wrapped := mw(detector)
+	if wrapped.Name() != "custom" {
+		t.Errorf("expected wrapped detector to keep its name, got %q", wrapped.Name())
+	}
+	if err := wrapped.Detect(context.Background(), "test"); !errors.Is(err, blocked) {
+		t.Errorf("expected detector error, got %v", err)
+	}
+	if seen != "custom" {
+		t.Errorf("expected middleware to see detector name, got %q", seen)
+	}
+}
+
+func TestAroundValidator(t *testing.T) {
+	calls := 0
+	mw := middleware.AroundValidator(func(ctx context.Context, name string, next middleware.NextCheck) error {
+		calls++
+		return next(ctx)
+	})
+
+	t.Run("keeps name", func(t *testing.T) {
+		wrapped := mw(validators.NewJSON())
+		if wrapped.Name() != "json" {
+			t.Errorf("expected name 'json', got %q", wrapped.Name())
+		}
+		if err := wrapped.Validate(context.Background(), "not json"); err == nil {
+			t.Error("expected validation error")
+		}
+		if _, ok := wrapped.(railguard.StreamValidator); ok {
+			t.Error("expected plain validator to stay a plain validator")
+		}
+	})
+
+	t.Run("keeps stream validation", func(t *testing.T) {
+		wrapped := mw(validators.NewMaxLength(5))
+		sv, ok := wrapped.(railguard.StreamValidator)
+		if !ok {
+			t.Fatal("expected wrapped MaxLength to implement StreamValidator")
+		}
+		if err := sv.ValidateStream(context.Background(), "too long"); err == nil {
+			t.Error("expected stream validation error")
+		}
+	})
+
+	if calls != 1 {
+		t.Errorf("expected middleware to run for Validate only, got %d calls", calls)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// Timing returns a ClientMiddleware that reports the duration and error of
+// every generation call to observe, e.g. to feed a latency histogram.
+func Timing(observe func(d time.Duration, err error)) railguard.ClientMiddleware {
+	return Around(func(ctx context.Context, next Next) (string, error) {
+		start := time.Now()
+		output, err := next(ctx)
+		observe(time.Since(start), err)
+		return output, err
+	})
+}
+
+// DetectorTiming returns a DetectorMiddleware that reports the name,
+// duration, and error of every Detect call to observe.
+func DetectorTiming(observe func(name string, d time.Duration, err error)) railguard.DetectorMiddleware {
+	return AroundDetector(func(ctx context.Context, name string, next NextCheck) error {
+		start := time.Now()
+		err := next(ctx)
+		observe(name, time.Since(start), err)
+		return err
+	})
+}
+
+// ValidatorTiming returns a ValidatorMiddleware that reports the name,
+// duration, and error of every Validate call to observe.
+func ValidatorTiming(observe func(name string, d time.Duration, err error)) railguard.ValidatorMiddleware {
+	return AroundValidator(func(ctx context.Context, name string, next NextCheck) error {
+		start := time.Now()
+		err := next(ctx)
+		observe(name, time.Since(start), err)
+		return err
+	})
+}
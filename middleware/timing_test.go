@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/middleware"
+)
+
+func TestTiming(t *testing.T) {
+	failure := errors.New("overloaded")
+	client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "", failure
+	})
+
+	var observed time.Duration
+	var observedErr error
+	wrapped := middleware.Timing(func(d time.Duration, err error) {
+		observed = d
+		observedErr = err
+	})(client)
+
+	_, _ = wrapped.Generate(context.Background(), "test")
+	if observed < 10*time.Millisecond {
+		t.Errorf("expected duration of at least 10ms, got %v", observed)
+	}
+	if !errors.Is(observedErr, failure) {
+		t.Errorf("expected observed error, got %v", observedErr)
+	}
+}
+
+func TestDetectorTiming(t *testing.T) {
+	var names []string
+	mw := middleware.DetectorTiming(func(name string, d time.Duration, err error) {
+		names = append(names, name)
+	})
+
+	detector := mw(railguard.DetectorFunc(func(ctx context.Context, prompt string) error { return nil }))
+	if err := detector.Detect(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "custom" {
+		t.Errorf("unexpected observed names: %v", names)
+	}
+}
+
+func TestValidatorTiming(t *testing.T) {
+	var names []string
+	mw := middleware.ValidatorTiming(func(name string, d time.Duration, err error) {
+		names = append(names, name)
+	})
+
+	validator := mw(railguard.ValidatorFunc(func(ctx context.Context, output string) error { return nil }))
+	if err := validator.Validate(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "custom" {
+		t.Errorf("unexpected observed names: %v", names)
+	}
+}
@@ -0,0 +1,201 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/middleware"
+)
+
+// tagClient returns a ClientMiddleware that appends tag to the output.
+func tagClient(tag string) railguard.ClientMiddleware {
+	return func(next railguard.Client) railguard.Client {
+		return railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			output, err := next.Generate(ctx, prompt)
+			return output + tag, err
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Run("first middleware is outermost", func(t *testing.T) {
+		var order []string
+		trace := func(name string) railguard.ClientMiddleware {
+			return func(next railguard.Client) railguard.Client {
+				return railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+					order = append(order, name)
+					return next.Generate(ctx, prompt)
+				})
+			}
+		}
+
+		client := railguard.Chain(trace("a"), trace("b"), trace("c"))(&mockClient{})
+		if _, err := client.Generate(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(order, ",") != "a,b,c" {
+			t.Errorf("expected order a,b,c, got %v", order)
+		}
+	})
+
+	t.Run("empty chain", func(t *testing.T) {
+		client := &mockClient{}
+		if got := railguard.Chain[railguard.ClientMiddleware]()(client); got != client {
+			t.Error("expected empty chain to return the client unchanged")
+		}
+	})
+}
+
+func TestWithClientMiddleware(t *testing.T) {
+	t.Run("wraps client", func(t *testing.T) {
+		// Middleware may come before the client in the options
+		g, err := railguard.New(
+			railguard.WithClientMiddleware(tagClient("-a")),
+			railguard.WithClient(&mockClient{}),
+			railguard.WithClientMiddleware(tagClient("-b")),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// b is innermost, so its tag is appended first
+		if result.Raw != "response-b-a" {
+			t.Errorf("expected 'response-b-a', got %q", result.Raw)
+		}
+	})
+
+	t.Run("rejects middleware that drops chat support", func(t *testing.T) {
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			return "ok", nil
+		})
+		_, err := railguard.New(
+			railguard.WithChatClient(chat),
+			railguard.WithClientMiddleware(tagClient("-a")),
+		)
+		if !errors.Is(err, railguard.ErrChatMiddleware) {
+			t.Errorf("expected ErrChatMiddleware, got %v", err)
+		}
+	})
+
+	t.Run("chat-aware middleware keeps roles", func(t *testing.T) {
+		var got []railguard.Message
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			got = messages
+			return "ok", nil
+		})
+		g, err := railguard.New(
+			railguard.WithChatClient(chat),
+			railguard.WithClientMiddleware(middleware.Around(func(ctx context.Context, next middleware.Next) (string, error) {
+				return next(ctx)
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		messages := []railguard.Message{
+			{Role: railguard.RoleSystem, Content: "be brief"},
+			{Role: railguard.RoleUser, Content: "hi"},
+		}
+		if _, err := g.RunMessages(context.Background(), messages); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 || got[0].Role != railguard.RoleSystem {
+			t.Errorf("expected messages to keep their roles, got %+v", got)
+		}
+	})
+
+	t.Run("nil middleware", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithClientMiddleware(nil),
+		)
+		if !errors.Is(err, railguard.ErrNilMiddleware) {
+			t.Errorf("expected ErrNilMiddleware, got %v", err)
+		}
+	})
+}
+
+func TestWithDetectorMiddleware(t *testing.T) {
+	var seen []string
+	mw := func(next railguard.Detector) railguard.Detector {
+		return &namedDetector{name: next.Name(), detect: func(ctx context.Context, prompt string) error {
+			seen = append(seen, next.Name())
+			return next.Detect(ctx, prompt)
+		}}
+	}
+
+	g, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithDetectorMiddleware(mw),
+		railguard.WithDetectors(&mockDetector{name: "first"}, &mockDetector{name: "second"}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	if _, err := g.Run(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(seen, ",") != "first,second" {
+		t.Errorf("expected both detectors to be wrapped, got %v", seen)
+	}
+
+	_, err = railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithDetectorMiddleware(nil),
+	)
+	if !errors.Is(err, railguard.ErrNilMiddleware) {
+		t.Errorf("expected ErrNilMiddleware, got %v", err)
+	}
+}
+
+func TestWithValidatorMiddleware(t *testing.T) {
+	reject := func(next railguard.Validator) railguard.Validator {
+		return railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+			return errors.New("rejected by middleware")
+		})
+	}
+
+	g, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithValidators(&mockValidator{name: "ok"}),
+		railguard.WithValidatorMiddleware(reject),
+		railguard.WithMaxRetries(1),
+	)
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	_, err = g.Run(context.Background(), "test")
+	var valErr *railguard.ValidationError
+	if !errors.As(err, &valErr) {
+		t.Errorf("expected ValidationError from middleware, got %v", err)
+	}
+
+	_, err = railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithValidatorMiddleware(nil),
+	)
+	if !errors.Is(err, railguard.ErrNilMiddleware) {
+		t.Errorf("expected ErrNilMiddleware, got %v", err)
+	}
+}
+
+// namedDetector is a Detector with a configurable name and behavior.
+type namedDetector struct {
+	name   string
+	detect func(ctx context.Context, prompt string) error
+}
+
+func (d *namedDetector) Detect(ctx context.Context, prompt string) error {
+	return d.detect(ctx, prompt)
+}
+func (d *namedDetector) Name() string { return d.name }
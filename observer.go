@@ -0,0 +1,272 @@
+package railguard
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives lifecycle events from the Guard pipeline.
+// It can be used to connect logging, metrics, and tracing without wrapping
+// every component. Embed NopObserver to implement only the callbacks you need.
+//
+// Callbacks are invoked synchronously from the pipeline and should return
+// quickly. Observers must be safe for concurrent use, since detectors may run
+// concurrently and a Guard may be shared between goroutines.
+type Observer interface {
+	// OnRunStart is called when Run, RunMessages, or Stream starts.
+	OnRunStart(ctx context.Context, e RunStartEvent)
+
+	// OnRunEnd is called when the run finishes, successfully or not.
+	OnRunEnd(ctx context.Context, e RunEndEvent)
+
+	// OnDetector is called after each detector has examined the prompt.
+	OnDetector(ctx context.Context, e DetectorEvent)
+
+	// OnGenerationStart is called before the client is called.
+	OnGenerationStart(ctx context.Context, e GenerationStartEvent)
+
+	// OnGenerationEnd is called after the client returns.
+	OnGenerationEnd(ctx context.Context, e GenerationEndEvent)
+
+	// OnTransformer is called after each transformer has run.
+	OnTransformer(ctx context.Context, e TransformerEvent)
+
+	// OnValidator is called after each validator has examined the output.
+	OnValidator(ctx context.Context, e ValidatorEvent)
+
+	// OnSchema is called after the output has been parsed against the schema.
+	// It is not called when no schema is configured.
+	OnSchema(ctx context.Context, e SchemaEvent)
+
+	// OnBackoff is called before waiting between attempts.
+	OnBackoff(ctx context.Context, e BackoffEvent)
+
+	// OnRetry is called after a failed attempt with the decision whether
+	// another attempt will be made.
+	OnRetry(ctx context.Context, e RetryEvent)
+}
+
+// RunStartEvent describes the start of a run.
+type RunStartEvent struct {
+	// Messages is the conversation being run. Run and Stream pass their
+	// prompt as a single user message.
+	Messages []Message
+
+	// Start is the time the run started.
+	Start time.Time
+}
+
+// RunEndEvent describes the end of a run.
+type RunEndEvent struct {
+	// Attempts is the number of generation attempts made.
+	Attempts int
+
+	// Duration is the total time spent in the run.
+	Duration time.Duration
+
+	// Result is the result of a successful run, or nil.
+	Result *Result
+
+	// Err is the error that ended the run, or nil on success.
+	Err error
+}
+
+// DetectorEvent describes a detector examining the prompt.
+type DetectorEvent struct {
+	// Detector is the name of the detector.
+	Detector string
+
+	// Duration is the time the detector took.
+	Duration time.Duration
+
+	// Err is the detector's error, or nil if the prompt passed.
+	Err error
+}
+
+// GenerationStartEvent describes the start of a generation attempt.
+type GenerationStartEvent struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Messages is the conversation sent to the client for this attempt.
+	Messages []Message
+}
+
+// GenerationEndEvent describes the end of a generation attempt.
+type GenerationEndEvent struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Duration is the time the client took.
+	Duration time.Duration
+
+	// Output is the raw output, or empty if generation failed.
+	Output string
+
+	// Err is the client's error, or nil on success.
+	Err error
+}
+
+// TransformerEvent describes a transformer rewriting the output.
+type TransformerEvent struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Transformer is the name of the transformer.
+	Transformer string
+
+	// Duration is the time the transformer took.
+	Duration time.Duration
+
+	// Err is the transformer's error, or nil on success.
+	Err error
+}
+
+// ValidatorEvent describes a validator examining the output.
+type ValidatorEvent struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Validator is the name of the validator.
+	Validator string
+
+	// Duration is the time the validator took.
+	Duration time.Duration
+
+	// Err is the validator's error, or nil if the output passed.
+	Err error
+}
+
+// SchemaEvent describes the output being parsed against the schema.
+type SchemaEvent struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Duration is the time parsing took.
+	Duration time.Duration
+
+	// Err is the SchemaError, or nil if the output matched the schema.
+	Err error
+
+	// JSONFixes lists the fixes applied if a lenient schema repaired the
+	// output before parsing it.
+	JSONFixes []JSONFix
+}
+
+// BackoffEvent describes a wait between attempts.
+type BackoffEvent struct {
+	// Attempt is the number of the attempt that will follow the wait.
+	Attempt int
+
+	// Delay is the time the pipeline will wait.
+	Delay time.Duration
+}
+
+// RetryEvent describes the decision made after a failed attempt.
+type RetryEvent struct {
+	// Attempt is the number of the attempt that failed.
+	Attempt int
+
+	// Err is the error that failed the attempt.
+	Err error
+
+	// Retry is true if another attempt will be made.
+	Retry bool
+}
+
+// NopObserver is an Observer that ignores all events.
+// Embed it in a struct to implement only some of the Observer callbacks.
+type NopObserver struct{}
+
+// OnRunStart implements Observer.
+func (NopObserver) OnRunStart(context.Context, RunStartEvent) {}
+
+// OnRunEnd implements Observer.
+func (NopObserver) OnRunEnd(context.Context, RunEndEvent) {}
+
+// OnDetector implements Observer.
+func (NopObserver) OnDetector(context.Context, DetectorEvent) {}
+
+// OnGenerationStart implements Observer.
+func (NopObserver) OnGenerationStart(context.Context, GenerationStartEvent) {}
+
+// OnGenerationEnd implements Observer.
+func (NopObserver) OnGenerationEnd(context.Context, GenerationEndEvent) {}
+
+// OnTransformer implements Observer.
+func (NopObserver) OnTransformer(context.Context, TransformerEvent) {}
+
+// OnValidator implements Observer.
+func (NopObserver) OnValidator(context.Context, ValidatorEvent) {}
+
+// OnSchema implements Observer.
+func (NopObserver) OnSchema(context.Context, SchemaEvent) {}
+
+// OnBackoff implements Observer.
+func (NopObserver) OnBackoff(context.Context, BackoffEvent) {}
+
+// OnRetry implements Observer.
+func (NopObserver) OnRetry(context.Context, RetryEvent) {}
+
+// observers fans events out to several observers in order.
+type observers []Observer
+
+func (o observers) OnRunStart(ctx context.Context, e RunStartEvent) {
+	for _, obs := range o {
+		obs.OnRunStart(ctx, e)
+	}
+}
+
+func (o observers) OnRunEnd(ctx context.Context, e RunEndEvent) {
+	for _, obs := range o {
+		obs.OnRunEnd(ctx, e)
+	}
+}
+
+func (o observers) OnDetector(ctx context.Context, e DetectorEvent) {
+	for _, obs := range o {
+		obs.OnDetector(ctx, e)
+	}
+}
+
+func (o observers) OnGenerationStart(ctx context.Context, e GenerationStartEvent) {
+	for _, obs := range o {
+		obs.OnGenerationStart(ctx, e)
+	}
+}
+
+func (o observers) OnGenerationEnd(ctx context.Context, e GenerationEndEvent) {
+	for _, obs := range o {
+		obs.OnGenerationEnd(ctx, e)
+	}
+}
+
+func (o observers) OnTransformer(ctx context.Context, e TransformerEvent) {
+	for _, obs := range o {
+		obs.OnTransformer(ctx, e)
+	}
+}
+
+func (o observers) OnValidator(ctx context.Context, e ValidatorEvent) {
+	for _, obs := range o {
+		obs.OnValidator(ctx, e)
+	}
+}
+
+func (o observers) OnSchema(ctx context.Context, e SchemaEvent) {
+	for _, obs := range o {
+		obs.OnSchema(ctx, e)
+	}
+}
+
+func (o observers) OnBackoff(ctx context.Context, e BackoffEvent) {
+	for _, obs := range o {
+		obs.OnBackoff(ctx, e)
+	}
+}
+
+func (o observers) OnRetry(ctx context.Context, e RetryEvent) {
+	for _, obs := range o {
+		obs.OnRetry(ctx, e)
+	}
+}
@@ -0,0 +1,221 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+// recordingObserver records a line for every event it receives.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+	end    railguard.RunEndEvent
+}
+
+func (o *recordingObserver) record(format string, args ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *recordingObserver) OnRunStart(ctx context.Context, e railguard.RunStartEvent) {
+	o.record("run start")
+}
+
+func (o *recordingObserver) OnRunEnd(ctx context.Context, e railguard.RunEndEvent) {
+	o.record("run end attempts=%d err=%v", e.Attempts, e.Err != nil)
+	o.end = e
+}
+
+func (o *recordingObserver) OnDetector(ctx context.Context, e railguard.DetectorEvent) {
+	o.record("detector %s err=%v", e.Detector, e.Err != nil)
+}
+
+func (o *recordingObserver) OnGenerationStart(ctx context.Context, e railguard.GenerationStartEvent) {
+	o.record("generation start %d", e.Attempt)
+}
+
+func (o *recordingObserver) OnGenerationEnd(ctx context.Context, e railguard.GenerationEndEvent) {
+	o.record("generation end %d output=%s", e.Attempt, e.Output)
+}
+
+func (o *recordingObserver) OnTransformer(ctx context.Context, e railguard.TransformerEvent) {
+	o.record("transformer %d %s", e.Attempt, e.Transformer)
+}
+
+func (o *recordingObserver) OnValidator(ctx context.Context, e railguard.ValidatorEvent) {
+	o.record("validator %d %s err=%v", e.Attempt, e.Validator, e.Err != nil)
+}
+
+func (o *recordingObserver) OnSchema(ctx context.Context, e railguard.SchemaEvent) {
+	o.record("schema %d err=%v", e.Attempt, e.Err != nil)
+}
+
+func (o *recordingObserver) OnBackoff(ctx context.Context, e railguard.BackoffEvent) {
+	o.record("backoff %d", e.Attempt)
+}
+
+func (o *recordingObserver) OnRetry(ctx context.Context, e railguard.RetryEvent) {
+	o.record("retry %d retry=%v", e.Attempt, e.Retry)
+}
+
+func TestObserver(t *testing.T) {
+	type Response struct {
+		Message string `json:"message"`
+	}
+
+	t.Run("receives pipeline events in order", func(t *testing.T) {
+		attempts := 0
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			attempts++
+			if attempts == 1 {
+				return `bad`, nil
+			}
+			return `{"message": "ok"}`, nil
+		})
+
+		obs := &recordingObserver{}
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(&Response{}),
+			railguard.WithDetectors(&mockDetector{name: "d1"}),
+			railguard.WithTransformers(railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+				return output, nil
+			})),
+			railguard.WithValidators(&mockValidator{name: "v1"}),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+			railguard.WithObserver(obs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []string{
+			"run start",
+			"detector d1 err=false",
+			"generation start 1",
+			"generation end 1 output=bad",
+			"transformer 1 custom",
+			"validator 1 v1 err=false",
+			"schema 1 err=true",
+			"retry 1 retry=true",
+			"backoff 2",
+			"generation start 2",
+			`generation end 2 output={"message": "ok"}`,
+			"transformer 2 custom",
+			"validator 2 v1 err=false",
+			"schema 2 err=false",
+			"run end attempts=2 err=false",
+		}
+		if !reflect.DeepEqual(obs.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", obs.events, want)
+		}
+		if obs.end.Result == nil || obs.end.Duration <= 0 {
+			t.Errorf("expected run end to carry result and duration, got %+v", obs.end)
+		}
+	})
+
+	t.Run("reports final failure", func(t *testing.T) {
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return "", errors.New("down")
+		})
+
+		obs := &recordingObserver{}
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+			railguard.WithObserver(obs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+
+		want := []string{
+			"run start",
+			"generation start 1",
+			"generation end 1 output=",
+			"retry 1 retry=true",
+			"backoff 2",
+			"generation start 2",
+			"generation end 2 output=",
+			"retry 2 retry=false",
+			"run end attempts=2 err=true",
+		}
+		if !reflect.DeepEqual(obs.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", obs.events, want)
+		}
+		var maxErr *railguard.MaxRetriesError
+		if !errors.As(obs.end.Err, &maxErr) {
+			t.Errorf("expected MaxRetriesError in run end, got %v", obs.end.Err)
+		}
+	})
+
+	t.Run("detection failure ends the run", func(t *testing.T) {
+		obs := &recordingObserver{}
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithDetectors(railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+				return errors.New("blocked")
+			})),
+			railguard.WithObserver(obs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, _ = g.Run(context.Background(), "test")
+
+		want := []string{
+			"run start",
+			"detector custom err=true",
+			"run end attempts=0 err=true",
+		}
+		if !reflect.DeepEqual(obs.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", obs.events, want)
+		}
+	})
+
+	t.Run("partial observers can embed NopObserver", func(t *testing.T) {
+		var starts int
+		obs := &startCounter{count: &starts}
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithObserver(obs),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if starts != 1 {
+			t.Errorf("expected 1 generation start, got %d", starts)
+		}
+	})
+}
+
+// startCounter counts generation starts and ignores all other events.
+type startCounter struct {
+	railguard.NopObserver
+	count *int
+}
+
+func (s *startCounter) OnGenerationStart(ctx context.Context, e railguard.GenerationStartEvent) {
+	*s.count++
+}
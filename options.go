@@ -1,6 +1,7 @@
 package railguard
 
 import (
+	"log/slog"
 	"time"
 )
 
@@ -15,15 +16,55 @@ func WithClient(client Client) Option {
 			return ErrNilClient
 		}
 		g.client = client
+		g.chat = nil
+		return nil
+	}
+}
+
+// WithChatClient sets a message-based LLM client for the Guard.
+// It can be used instead of WithClient. Guard.Run sends its prompt as a single
+// user message, and Guard.RunMessages passes the conversation through unchanged.
+func WithChatClient(client ChatClient) Option {
+	return func(g *Guard) error {
+		if client == nil {
+			return ErrNilClient
+		}
+		g.chat = client
+		g.client = AsClient(client)
+		return nil
+	}
+}
+
+// WithDetectRoles sets which message roles detectors examine in RunMessages.
+// By default only user messages are examined, so trusted system prompts and
+// earlier assistant turns are not scanned.
+func WithDetectRoles(roles ...Role) Option {
+	return func(g *Guard) error {
+		if len(roles) == 0 {
+			return ErrNoDetectRoles
+		}
+		g.detectRoles = append([]Role(nil), roles...)
 		return nil
 	}
 }
 
 // WithSchema sets the JSON schema for output validation.
-// The provided value must be a pointer to a struct.
+// The provided value must be a pointer to a struct, or a *Schema created with
+// NewSchema. A *Schema is copied, so that Guards can share it: each keeps the
+// schema's settings apart from those given with WithStrictSchema,
+// WithRequiredFields, and WithLenientSchema, and later changes to the schema
+// do not affect the Guard.
 // When set, all LLM outputs will be validated against this schema.
 func WithSchema(v interface{}) Option {
 	return func(g *Guard) error {
+		if schema, ok := v.(*Schema); ok {
+			if schema == nil {
+				return ErrInvalidSchema
+			}
+			c := *schema
+			g.schema = &c
+			return nil
+		}
 		schema, err := NewSchema(v)
 		if err != nil {
 			return err
@@ -48,6 +89,51 @@ func WithDetectors(detectors ...Detector) Option {
 	}
 }
 
+// WithConcurrentDetectors sets whether detectors run in parallel instead of
+// in sequence. This reduces latency when slow detectors, such as LLM-based
+// classifiers, are combined with cheap ones. In fail-fast mode the remaining
+// detectors are canceled as soon as one rejects the prompt, and are not
+// reported to observers.
+func WithConcurrentDetectors(concurrent bool) Option {
+	return func(g *Guard) error {
+		g.concurrentDetectors = concurrent
+		return nil
+	}
+}
+
+// WithAttemptTimeout limits how long each generation call of Run,
+// RunMessages, and Stream may run, separately from the overall timeout set
+// with WithTimeout. A generation that exceeds it fails with a GenerationError
+// wrapping ErrAttemptTimeout and is retried like any other generation failure,
+// except in Stream, which makes a single attempt. With a FallbackClient, the
+// timeout applies to each of its clients, so an attempt may take longer.
+// A timeout of 0 means no per-attempt timeout.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(g *Guard) error {
+		if timeout < 0 {
+			return ErrInvalidTimeout
+		}
+		g.attemptTimeout = timeout
+		return nil
+	}
+}
+
+// WithDetectorTimeout limits how long each detector may run.
+// When a detector exceeds the timeout, the policy decides whether the prompt
+// is rejected (FailClosed) or allowed (FailOpen). The timeout applies to each
+// detector individually; use DetectorWithTimeout to set different timeouts
+// for specific detectors. A timeout of 0 means no per-detector timeout.
+func WithDetectorTimeout(timeout time.Duration, policy TimeoutPolicy) Option {
+	return func(g *Guard) error {
+		if timeout < 0 {
+			return ErrInvalidTimeout
+		}
+		g.detectorTimeout = timeout
+		g.detectorTimeoutPolicy = policy
+		return nil
+	}
+}
+
 // WithValidators adds one or more validators to the Guard.
 // Validators are run in order after each generation.
 // Validation failures may be retried based on the retry configuration.
@@ -63,6 +149,67 @@ func WithValidators(validators ...Validator) Option {
 	}
 }
 
+// WithTransformers adds one or more transformers to the Guard.
+// Transformers are run in order after each generation, before validators and
+// schema parsing. Each transformer receives the output of the previous one.
+// Transformation failures may be retried based on the retry configuration.
+func WithTransformers(transformers ...Transformer) Option {
+	return func(g *Guard) error {
+		for _, t := range transformers {
+			if t == nil {
+				return ErrNilTransformer
+			}
+		}
+		g.transformers = append(g.transformers, transformers...)
+		return nil
+	}
+}
+
+// WithClientMiddleware wraps the client with the given middlewares.
+// The first middleware is the outermost, as with Chain. Middlewares are
+// applied when the Guard is created, regardless of the order of options.
+// With a chat client, every middleware must keep chat support, or New returns
+// ErrChatMiddleware.
+func WithClientMiddleware(middlewares ...ClientMiddleware) Option {
+	return func(g *Guard) error {
+		for _, mw := range middlewares {
+			if mw == nil {
+				return ErrNilMiddleware
+			}
+		}
+		g.clientMiddleware = append(g.clientMiddleware, middlewares...)
+		return nil
+	}
+}
+
+// WithDetectorMiddleware wraps every detector with the given middlewares.
+// The first middleware is the outermost, as with Chain.
+func WithDetectorMiddleware(middlewares ...DetectorMiddleware) Option {
+	return func(g *Guard) error {
+		for _, mw := range middlewares {
+			if mw == nil {
+				return ErrNilMiddleware
+			}
+		}
+		g.detectorMiddleware = append(g.detectorMiddleware, middlewares...)
+		return nil
+	}
+}
+
+// WithValidatorMiddleware wraps every validator with the given middlewares.
+// The first middleware is the outermost, as with Chain.
+func WithValidatorMiddleware(middlewares ...ValidatorMiddleware) Option {
+	return func(g *Guard) error {
+		for _, mw := range middlewares {
+			if mw == nil {
+				return ErrNilMiddleware
+			}
+		}
+		g.validatorMiddleware = append(g.validatorMiddleware, middlewares...)
+		return nil
+	}
+}
+
 // WithRetry sets the retry configuration for the Guard.
 // This controls how generation and validation failures are retried.
 func WithRetry(config RetryConfig) Option {
@@ -88,6 +235,108 @@ func WithMaxRetries(maxRetries int) Option {
 	}
 }
 
+// WithRetryPolicy sets the policy that decides whether a failed attempt is
+// retried and how long to wait before the next one. It replaces
+// DefaultRetryPolicy, including its use of the RetryConfig backoff delays;
+// RetryConfig.MaxAttempts still limits the number of attempts.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(g *Guard) error {
+		if policy == nil {
+			return ErrNilRetryPolicy
+		}
+		g.retryPolicy = policy
+		return nil
+	}
+}
+
+// WithRetryBudget limits retries with a RetryBudget, which may be shared by
+// several Guards. A retry refused by the budget ends the run with a
+// RetryAbortedError whose reason is ErrRetryBudgetExhausted.
+func WithRetryBudget(budget *RetryBudget) Option {
+	return func(g *Guard) error {
+		if budget == nil {
+			return ErrNilRetryBudget
+		}
+		g.retryBudget = budget
+		return nil
+	}
+}
+
+// WithHedging sends a second request when an attempt has not produced an
+// accepted output within config.Delay. Whichever output passes transformers,
+// validators, and the schema first is used, and the other request is
+// canceled; the attempt ends once it has stopped. config.Budget limits the
+// fraction of attempts that may hedge, counting the attempts made so far, so
+// a budget of 0.1 allows the first hedge on the 10th attempt.
+// Hedged requests run concurrently, so the client, transformers, and
+// validators must be safe for concurrent use.
+func WithHedging(config HedgeConfig) Option {
+	return func(g *Guard) error {
+		if err := config.Validate(); err != nil {
+			return ErrInvalidHedgeConfig
+		}
+		g.hedge = config
+		g.hedgeBudget = &hedgeBudget{ratio: config.Budget}
+		return nil
+	}
+}
+
+// WithRepair enables repair retries using DefaultRepairPrompter.
+// When an output is rejected by a transformer, validator, or the schema, the
+// next attempt sends a follow-up prompt containing the previous output and the
+// error that rejected it, instead of resending the identical prompt.
+func WithRepair() Option {
+	return WithRepairPrompter(DefaultRepairPrompter())
+}
+
+// WithRepairPrompter enables repair retries using a custom RepairPrompter.
+// See WithRepair for details.
+func WithRepairPrompter(prompter RepairPrompter) Option {
+	return func(g *Guard) error {
+		if prompter == nil {
+			return ErrNilRepairPrompter
+		}
+		g.repair = prompter
+		return nil
+	}
+}
+
+// WithObserver adds one or more observers that receive lifecycle events from
+// the pipeline, such as detector results, generation timings, and retry
+// decisions. Observers are called in the order they were added.
+func WithObserver(observers ...Observer) Option {
+	return func(g *Guard) error {
+		for _, o := range observers {
+			if o == nil {
+				return ErrNilObserver
+			}
+		}
+		g.observer = append(g.observer, observers...)
+		return nil
+	}
+}
+
+// WithLogger emits structured log records for each pipeline phase to logger.
+// Records carry detector, transformer, and validator names, attempt numbers,
+// durations, the error kind (e.g. "ValidationError"), and the request ID set
+// with WithRequestID. Stage results are logged at debug level when they pass
+// and at warn level when they fail.
+//
+// Error messages can quote prompts, outputs, or API responses, so records
+// above debug level carry only the error kind and, for schema errors, the
+// kind and path of each issue. Error messages, prompts, and outputs are only
+// logged in debug-level records. Use LogBodyLimit and LogRedact to truncate
+// or mask them.
+func WithLogger(logger *slog.Logger, opts ...LogOption) Option {
+	return func(g *Guard) error {
+		if logger == nil {
+			return ErrNilLogger
+		}
+		g.observer = append(g.observer, newSlogObserver(logger, opts...))
+		return nil
+	}
+}
+
 // WithTimeout sets a timeout for the entire Run operation.
 // The timeout applies to detection, generation, and validation combined.
 // A timeout of 0 means no timeout (the context's deadline is used instead).
@@ -101,6 +350,18 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithCollectAll sets whether every detector and validator runs even after
+// one of them fails. When enabled, failures are reported together in a
+// MultiError, in the order the detectors or validators were configured, and
+// can be inspected with errors.Is and errors.As.
+// By default, the pipeline fails fast on the first failure.
+func WithCollectAll(collect bool) Option {
+	return func(g *Guard) error {
+		g.collectAll = collect
+		return nil
+	}
+}
+
 // WithStrictSchema sets whether the schema should reject unknown fields.
 // By default, strict mode is enabled to prevent hallucinated fields.
 // This option only has an effect if WithSchema is also used.
@@ -110,6 +371,40 @@ func WithStrictSchema(strict bool) Option {
 			g.schema.WithStrict(strict)
 		}
 		g.strictSchema = strict
+		g.strictSchemaSet = true
+		return nil
+	}
+}
+
+// WithRequiredFields sets whether schema fields are required by default.
+// By default, a field that is not a pointer and has no omitempty option must
+// be present in the output; see Schema.WithRequiredFields.
+// This option only has an effect if WithSchema is also used.
+func WithRequiredFields(required bool) Option {
+	return func(g *Guard) error {
+		if g.schema != nil {
+			g.schema.WithRequiredFields(required)
+		}
+		g.requiredFields = required
+		g.requiredFieldsSet = true
+		return nil
+	}
+}
+
+// WithLenientSchema sets whether the schema repairs almost-JSON before
+// parsing, such as trailing commas, single quotes, unquoted keys, comments,
+// Python True/None, or an object truncated at the token limit, instead of
+// paying for a retry. Repair is only attempted when the output is not valid
+// JSON. Repaired outputs are reported in Metadata.JSONRepaired and
+// Metadata.JSONFixes. By default, lenient mode is disabled.
+// This option only has an effect if WithSchema is also used.
+func WithLenientSchema(lenient bool) Option {
+	return func(g *Guard) error {
+		if g.schema != nil {
+			g.schema.WithLenient(lenient)
+		}
+		g.lenientSchema = lenient
+		g.lenientSchemaSet = true
 		return nil
 	}
 }
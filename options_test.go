@@ -48,6 +48,42 @@ func TestWithClient(t *testing.T) {
 	})
 }
 
+func TestWithChatClient(t *testing.T) {
+	t.Run("valid chat client", func(t *testing.T) {
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			return "response", nil
+		})
+
+		g, err := railguard.New(railguard.WithChatClient(chat))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Client() == nil {
+			t.Error("client should not be nil")
+		}
+		if g.ChatClient() == nil {
+			t.Error("chat client should not be nil")
+		}
+	})
+
+	t.Run("nil chat client", func(t *testing.T) {
+		_, err := railguard.New(railguard.WithChatClient(nil))
+		if !errors.Is(err, railguard.ErrNilClient) {
+			t.Errorf("expected ErrNilClient, got %v", err)
+		}
+	})
+}
+
+func TestWithDetectRoles(t *testing.T) {
+	_, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithDetectRoles(),
+	)
+	if !errors.Is(err, railguard.ErrNoDetectRoles) {
+		t.Errorf("expected ErrNoDetectRoles, got %v", err)
+	}
+}
+
 func TestWithSchema(t *testing.T) {
 	type Response struct {
 		Data string `json:"data"`
@@ -66,13 +102,76 @@ func TestWithSchema(t *testing.T) {
 		}
 	})
 
-	t.Run("invalid schema", func(t *testing.T) {
-		_, err := railguard.New(
+	t.Run("existing schema", func(t *testing.T) {
+		schema, err := railguard.NewSchema(&Response{})
+		if err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		g, err := railguard.New(
 			railguard.WithClient(&mockClient{}),
-			railguard.WithSchema("not a struct"),
+			railguard.WithSchema(schema),
 		)
-		if !errors.Is(err, railguard.ErrInvalidSchema) {
-			t.Errorf("expected ErrInvalidSchema, got %v", err)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Schema() == schema || g.Schema().TargetType() != schema.TargetType() {
+			t.Error("expected a copy of the schema")
+		}
+	})
+
+	t.Run("shared schema", func(t *testing.T) {
+		schema, err := railguard.NewSchema(&Response{})
+		if err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return `{"data": "ok", "extra": 1}`, nil
+		})
+		strict, err := railguard.New(railguard.WithClient(client), railguard.WithSchema(schema))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Building a Guard from the shared schema while another one runs
+		// must not change the running Guard
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 10; i++ {
+				_, _ = strict.Run(context.Background(), "test")
+			}
+		}()
+		loose, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(schema),
+			railguard.WithStrictSchema(false),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		<-done
+
+		if !schema.IsStrict() || !strict.Schema().IsStrict() || loose.Schema().IsStrict() {
+			t.Errorf("expected each Guard to keep its own setting, got shared %v, strict %v, loose %v",
+				schema.IsStrict(), strict.Schema().IsStrict(), loose.Schema().IsStrict())
+		}
+		if _, err := strict.Run(context.Background(), "test"); err == nil {
+			t.Error("expected the strict Guard to reject the unknown field")
+		}
+		if _, err := loose.Run(context.Background(), "test"); err != nil {
+			t.Errorf("expected the loose Guard to accept the unknown field, got %v", err)
+		}
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		for _, v := range []interface{}{"not a struct", (*railguard.Schema)(nil)} {
+			_, err := railguard.New(
+				railguard.WithClient(&mockClient{}),
+				railguard.WithSchema(v),
+			)
+			if !errors.Is(err, railguard.ErrInvalidSchema) {
+				t.Errorf("%v: expected ErrInvalidSchema, got %v", v, err)
+			}
 		}
 	})
 }
@@ -155,6 +254,48 @@ func TestWithValidators(t *testing.T) {
 	})
 }
 
+func TestWithTransformers(t *testing.T) {
+	t.Run("valid transformers", func(t *testing.T) {
+		noop := railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+			return output, nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithTransformers(noop, noop),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if transformers := g.Transformers(); len(transformers) != 2 {
+			t.Errorf("expected 2 transformers, got %d", len(transformers))
+		}
+	})
+
+	t.Run("nil transformer", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithTransformers(nil),
+		)
+		if !errors.Is(err, railguard.ErrNilTransformer) {
+			t.Errorf("expected ErrNilTransformer, got %v", err)
+		}
+	})
+}
+
+func TestWithRepairPrompter(t *testing.T) {
+	t.Run("nil prompter", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithRepairPrompter(nil),
+		)
+		if !errors.Is(err, railguard.ErrNilRepairPrompter) {
+			t.Errorf("expected ErrNilRepairPrompter, got %v", err)
+		}
+	})
+}
+
 func TestWithRetry(t *testing.T) {
 	t.Run("valid config", func(t *testing.T) {
 		config := railguard.RetryConfig{
@@ -189,6 +330,26 @@ func TestWithRetry(t *testing.T) {
 	})
 }
 
+func TestWithRetryPolicy(t *testing.T) {
+	_, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithRetryPolicy(nil),
+	)
+	if !errors.Is(err, railguard.ErrNilRetryPolicy) {
+		t.Errorf("expected ErrNilRetryPolicy, got %v", err)
+	}
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	_, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithRetryBudget(nil),
+	)
+	if !errors.Is(err, railguard.ErrNilRetryBudget) {
+		t.Errorf("expected ErrNilRetryBudget, got %v", err)
+	}
+}
+
 func TestWithMaxRetries(t *testing.T) {
 	t.Run("valid max retries", func(t *testing.T) {
 		_, err := railguard.New(
@@ -243,3 +404,203 @@ func TestWithTimeout(t *testing.T) {
 	})
 }
 
+
+func TestWithAttemptTimeout(t *testing.T) {
+	t.Run("negative timeout", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithAttemptTimeout(-time.Second),
+		)
+		if !errors.Is(err, railguard.ErrInvalidTimeout) {
+			t.Errorf("expected ErrInvalidTimeout, got %v", err)
+		}
+	})
+
+	t.Run("zero timeout", func(t *testing.T) {
+		_, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithAttemptTimeout(0),
+		)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestWithStrictSchema(t *testing.T) {
+	type Response struct {
+		Data string `json:"data"`
+	}
+
+	t.Run("applies when set after schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithSchema(&Response{}),
+			railguard.WithStrictSchema(false),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Schema().IsStrict() {
+			t.Error("expected non-strict schema")
+		}
+	})
+
+	t.Run("applies when set before schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithStrictSchema(false),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Schema().IsStrict() {
+			t.Error("expected non-strict schema")
+		}
+	})
+
+	t.Run("defaults to strict", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !g.Schema().IsStrict() {
+			t.Error("expected strict schema")
+		}
+	})
+}
+
+func TestWithRequiredFields(t *testing.T) {
+	type Response struct {
+		Data string `json:"data"`
+	}
+
+	t.Run("applies when set after schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithSchema(&Response{}),
+			railguard.WithRequiredFields(false),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Schema().RequiresFields() {
+			t.Error("expected fields to be optional")
+		}
+	})
+
+	t.Run("applies when set before schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithRequiredFields(false),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if g.Schema().RequiresFields() {
+			t.Error("expected fields to be optional")
+		}
+	})
+
+	t.Run("applies to typed guards", func(t *testing.T) {
+		g, _ := railguard.New(
+			railguard.WithClient(railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+				return `{}`, nil
+			})),
+			railguard.WithRequiredFields(false),
+		)
+		if _, err := railguard.RunAs[Response](context.Background(), g, "test"); err != nil {
+			t.Errorf("expected missing field to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("defaults to required", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(&mockClient{}),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !g.Schema().RequiresFields() {
+			t.Error("expected fields to be required")
+		}
+	})
+}
+
+func TestWithDetectorTimeout(t *testing.T) {
+	_, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithDetectorTimeout(-time.Second, railguard.FailOpen),
+	)
+	if !errors.Is(err, railguard.ErrInvalidTimeout) {
+		t.Errorf("expected ErrInvalidTimeout, got %v", err)
+	}
+}
+
+func TestWithObserver(t *testing.T) {
+	_, err := railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithObserver(nil),
+	)
+	if !errors.Is(err, railguard.ErrNilObserver) {
+		t.Errorf("expected ErrNilObserver, got %v", err)
+	}
+}
+
+func TestWithLenientSchema(t *testing.T) {
+	type Response struct {
+		Data string `json:"data"`
+	}
+
+	for _, opts := range [][]railguard.Option{
+		{railguard.WithSchema(&Response{}), railguard.WithLenientSchema(true)},
+		{railguard.WithLenientSchema(true), railguard.WithSchema(&Response{})},
+	} {
+		g, err := railguard.New(append(opts, railguard.WithClient(&mockClient{}))...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !g.Schema().IsLenient() {
+			t.Error("expected schema to be lenient")
+		}
+	}
+
+	g, err := railguard.New(railguard.WithClient(&mockClient{}), railguard.WithSchema(&Response{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Schema().IsLenient() {
+		t.Error("expected schema not to be lenient by default")
+	}
+
+	// A lenient schema stays lenient unless the option is given
+	schema, err := railguard.NewSchema(&Response{})
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	schema.WithLenient(true)
+	g, err = railguard.New(railguard.WithClient(&mockClient{}), railguard.WithSchema(schema))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !g.Schema().IsLenient() {
+		t.Error("expected pre-configured schema to stay lenient")
+	}
+	g, err = railguard.New(
+		railguard.WithClient(&mockClient{}),
+		railguard.WithSchema(schema),
+		railguard.WithLenientSchema(false),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Schema().IsLenient() {
+		t.Error("expected WithLenientSchema(false) to override the schema")
+	}
+}
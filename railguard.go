@@ -2,6 +2,8 @@ package railguard
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -9,19 +11,52 @@ import (
 // It provides a pipeline for:
 //  1. Detection - Pre-generation safety checks (e.g., prompt injection detection)
 //  2. Generation - Calling the LLM client
-//  3. Validation - Post-generation output validation (e.g., JSON syntax)
-//  4. Schema - Structured output parsing
+//  3. Transformation - Output rewriting (e.g., stripping code fences)
+//  4. Validation - Post-generation output validation (e.g., JSON syntax)
+//  5. Schema - Structured output parsing
 //
-// Detection failures are fatal and not retried. Generation and validation
-// failures may be retried based on the retry configuration.
+// Detection failures are fatal and not retried. Generation, transformation,
+// and validation failures may be retried based on the retry configuration.
 type Guard struct {
 	client       Client
+	chat         ChatClient
+	detectRoles  []Role
 	detectors    []Detector
+	transformers []Transformer
 	validators   []Validator
 	schema       *Schema
 	retry        RetryConfig
+	retryPolicy  RetryPolicy
+	retryBudget  *RetryBudget
+	hedge        HedgeConfig
+	hedgeBudget  *hedgeBudget
 	timeout      time.Duration
-	strictSchema bool
+	// attemptTimeout limits each generation call; see WithAttemptTimeout.
+	attemptTimeout time.Duration
+	strictSchema   bool
+	// strictSchemaSet records whether WithStrictSchema was used, since
+	// schemas default to strict mode while strictSchema defaults to false.
+	strictSchemaSet bool
+	requiredFields  bool
+	// requiredFieldsSet records whether WithRequiredFields was used, like
+	// strictSchemaSet.
+	requiredFieldsSet bool
+	// lenientSchema enables JSON repair; see WithLenientSchema.
+	lenientSchema bool
+	// lenientSchemaSet records whether WithLenientSchema was used, so that
+	// a schema passed to WithSchema keeps its own setting otherwise.
+	lenientSchemaSet bool
+	repair           RepairPrompter
+	collectAll       bool
+	observer         observers
+
+	concurrentDetectors   bool
+	detectorTimeout       time.Duration
+	detectorTimeoutPolicy TimeoutPolicy
+
+	clientMiddleware    []ClientMiddleware
+	detectorMiddleware  []DetectorMiddleware
+	validatorMiddleware []ValidatorMiddleware
 }
 
 // Result contains the output from a successful Guard.Run call.
@@ -29,6 +64,10 @@ type Result struct {
 	// Raw is the raw string output from the LLM.
 	Raw string
 
+	// Output is the output after all transformers have run. It is the text
+	// that validators and the schema saw. Without transformers it equals Raw.
+	Output string
+
 	// Parsed is the structured output, populated when a schema is configured.
 	// It will be a pointer to the schema's target type.
 	Parsed interface{}
@@ -44,6 +83,39 @@ type Metadata struct {
 
 	// Duration is the total time spent in Run, including all retries.
 	Duration time.Duration
+
+	// RepairAttempts lists the attempts (1 = first attempt) that sent a repair
+	// prompt instead of the original prompt. It is empty unless repair is enabled.
+	RepairAttempts []int
+
+	// Trace records every attempt of the run in order, including the
+	// rejected attempts that preceded the successful one.
+	Trace []AttemptRecord
+
+	// Client is the name of the client that produced the output when using a
+	// FallbackClient. It is empty for clients without names.
+	Client string
+
+	// ClientAttempts is the number of calls made to each named client of a
+	// FallbackClient, including failed calls. It is nil for clients without names.
+	ClientAttempts map[string]int
+
+	// StopReason is the reason the provider stopped generating the output,
+	// e.g. "end_turn" or "max_tokens". It is empty unless the client reports
+	// it with SetStopReason.
+	StopReason string
+
+	// Hedges is the number of hedged requests sent during the run.
+	// It is zero unless hedging is enabled with WithHedging.
+	Hedges int
+
+	// JSONRepaired is true if the output was not valid JSON and was repaired
+	// before parsing. It is false unless WithLenientSchema is enabled.
+	JSONRepaired bool
+
+	// JSONFixes lists the fixes applied to the output, e.g. FixTrailingComma.
+	// It is empty unless JSONRepaired is true.
+	JSONFixes []JSONFix
 }
 
 // New creates a new Guard with the provided options.
@@ -58,7 +130,8 @@ type Metadata struct {
 //	)
 func New(opts ...Option) (*Guard, error) {
 	g := &Guard{
-		retry: DefaultRetryConfig(),
+		retry:       DefaultRetryConfig(),
+		detectRoles: []Role{RoleUser},
 	}
 
 	// Apply options
@@ -72,26 +145,65 @@ func New(opts ...Option) (*Guard, error) {
 	if g.client == nil {
 		return nil, ErrNoClient
 	}
+	if g.chat == nil {
+		g.chat = AsChatClient(g.client)
+	}
+	if err := g.applyMiddleware(); err != nil {
+		return nil, err
+	}
+	if g.retryPolicy == nil {
+		g.retryPolicy = DefaultRetryPolicy(g.retry)
+	}
 
-	// Apply strict schema setting if schema was created before the option
-	if g.schema != nil && !g.strictSchema {
-		// strictSchema defaults to false, but schema defaults to strict=true
-		// Only change if explicitly set to non-strict
+	// Apply schema settings in case the schema was set after the options
+	if g.schema != nil {
+		g.configureSchema(g.schema)
 	}
 
 	return g, nil
 }
 
+// configureSchema applies the schema settings of WithStrictSchema,
+// WithRequiredFields, and WithLenientSchema to schema, keeping its defaults
+// for settings not given.
+func (g *Guard) configureSchema(schema *Schema) {
+	if g.strictSchemaSet {
+		schema.WithStrict(g.strictSchema)
+	}
+	if g.requiredFieldsSet {
+		schema.WithRequiredFields(g.requiredFields)
+	}
+	if g.lenientSchemaSet {
+		schema.WithLenient(g.lenientSchema)
+	}
+}
+
 // Run executes the Guard pipeline for the given prompt.
 // The pipeline consists of:
 //  1. Apply timeout (if configured)
 //  2. Run detectors (fail fast, no retry)
-//  3. Retry loop: generate → validate → parse schema
+//  3. Retry loop: generate → transform → validate → parse schema
 //
+// The prompt is treated as a single user message; see RunMessages.
 // Returns a Result on success, or an error if the pipeline fails.
 func (g *Guard) Run(ctx context.Context, prompt string) (*Result, error) {
+	return g.RunMessages(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+// RunMessages executes the Guard pipeline for the given conversation.
+// Detectors only see the content of messages whose role was selected with
+// WithDetectRoles (user messages by default), so trusted system prompts are
+// not scanned. Generation uses the configured ChatClient, or flattens the
+// conversation into a prompt when only a Client was provided.
+//
+// Returns a Result on success, or an error if the pipeline fails.
+func (g *Guard) RunMessages(ctx context.Context, messages []Message) (*Result, error) {
 	startTime := time.Now()
 
+	if len(messages) == 0 {
+		return nil, ErrNoMessages
+	}
+
 	// Apply timeout if configured
 	if g.timeout > 0 {
 		var cancel context.CancelFunc
@@ -99,103 +211,404 @@ func (g *Guard) Run(ctx context.Context, prompt string) (*Result, error) {
 		defer cancel()
 	}
 
+	ctx, state := withRunState(ctx)
+	g.observer.OnRunStart(ctx, RunStartEvent{Messages: messages, Start: startTime})
+	result, attempts, err := g.run(ctx, state, messages, startTime)
+	g.observer.OnRunEnd(ctx, RunEndEvent{
+		Attempts: attempts,
+		Duration: time.Since(startTime),
+		Result:   result,
+		Err:      err,
+	})
+
+	return result, err
+}
+
+// run executes detection and the retry loop for RunMessages.
+// It returns the number of attempts made alongside the result.
+func (g *Guard) run(ctx context.Context, state *runState, messages []Message, startTime time.Time) (*Result, int, error) {
 	// Phase 1: Detection (fail fast, no retry)
-	if err := g.runDetectors(ctx, prompt); err != nil {
-		return nil, err
+	if err := g.runDetectors(ctx, detectionInput(messages, g.detectRoles)); err != nil {
+		return nil, 0, err
 	}
 
-	// Phase 2 & 3: Generation, Validation, and Schema (with retry)
+	// Phase 2 & 3: Generation, Transformation, Validation, and Schema (with retry)
 	var lastErr error
-	for attempt := 0; attempt < g.retry.MaxAttempts; attempt++ {
+	var repairAttempts []int
+	var trace []AttemptRecord
+	attemptMessages := messages
+	repairing := false
+	var backoff time.Duration
+	for attempt := 1; attempt <= g.retry.MaxAttempts; attempt++ {
 		// Backoff before retry (not before first attempt)
-		if attempt > 0 {
-			if err := g.retry.backoff(ctx, attempt); err != nil {
-				return nil, err
+		if attempt > 1 {
+			g.observer.OnBackoff(ctx, BackoffEvent{Attempt: attempt, Delay: backoff})
+			if err := sleep(ctx, backoff); err != nil {
+				return nil, attempt - 1, err
 			}
 		}
-
-		// Generate
-		output, err := g.client.Generate(ctx, prompt)
-		if err != nil {
-			lastErr = &GenerationError{Err: err}
-			if !shouldRetry(lastErr) {
-				return nil, lastErr
-			}
-			continue
+		if repairing {
+			repairAttempts = append(repairAttempts, attempt)
 		}
 
-		// Validate
-		if err := g.runValidators(ctx, output); err != nil {
+		attemptStart := time.Now()
+		state.beginAttempt()
+		record, transformed, parsed := g.attempt(ctx, attempt, attemptMessages)
+		record.Backoff = backoff
+		record.Client = state.lastClient()
+		record.StopReason = state.lastStopReason()
+		trace = append(trace, record)
+
+		if err := record.Err; err != nil {
 			lastErr = err
-			if !shouldRetry(lastErr) {
-				return nil, lastErr
+			if isRepairable(err) {
+				// Lets a FallbackClient move on from a client whose outputs keep being rejected
+				state.recordRejection()
 			}
-			continue
-		}
+			remaining := remainingTime(ctx)
+			retryable, delay := g.retryPolicy.Retry(err, attempt, remaining)
+			retry := retryable && attempt < g.retry.MaxAttempts
 
-		// Parse schema
-		parsed, err := g.parseSchema(output)
-		if err != nil {
-			lastErr = &SchemaError{Err: err}
-			if !shouldRetry(lastErr) {
-				return nil, lastErr
+			// Skip a retry that cannot finish before the deadline, assuming
+			// the next attempt takes as long as this one, or that the retry
+			// budget does not allow
+			var abort error
+			switch {
+			case !retry:
+			case delay+time.Since(attemptStart) > remaining:
+				abort = ErrInsufficientTime
+			case g.retryBudget != nil && !g.retryBudget.allowRetry():
+				abort = ErrRetryBudgetExhausted
+			}
+			g.observer.OnRetry(ctx, RetryEvent{Attempt: attempt, Err: err, Retry: retry && abort == nil})
+			if !retryable {
+				return nil, attempt, lastErr
+			}
+			if abort != nil {
+				return nil, attempt, &RetryAbortedError{
+					Reason:   abort,
+					Attempts: attempt,
+					LastErr:  lastErr,
+					Trace:    trace,
+				}
+			}
+			backoff = delay
+			// Rejected outputs are fed back to the model when repair is enabled.
+			// Generation errors resend the previous messages unchanged.
+			if g.repair != nil && isRepairable(err) {
+				attemptMessages = repairMessages(messages, g.repair, RepairRequest{
+					Output:  record.Raw,
+					Err:     err,
+					Attempt: attempt,
+				})
+				repairing = true
 			}
 			continue
 		}
 
 		// Success!
+		if g.retryBudget != nil {
+			g.retryBudget.recordSuccess()
+		}
 		return &Result{
-			Raw:    output,
+			Raw:    record.Raw,
+			Output: transformed,
 			Parsed: parsed,
 			Metadata: Metadata{
-				Attempts: attempt + 1,
-				Duration: time.Since(startTime),
+				Attempts:       attempt,
+				Duration:       time.Since(startTime),
+				RepairAttempts: repairAttempts,
+				Trace:          trace,
+				Client:         record.Client,
+				ClientAttempts: state.attempts(),
+				StopReason:     record.StopReason,
+				Hedges:         countHedges(trace),
+				JSONRepaired:   len(record.JSONFixes) > 0,
+				JSONFixes:      record.JSONFixes,
 			},
-		}, nil
+		}, attempt, nil
 	}
 
 	// Max retries exceeded
-	return nil, &MaxRetriesError{
+	return nil, g.retry.MaxAttempts, &MaxRetriesError{
 		Attempts: g.retry.MaxAttempts,
 		LastErr:  lastErr,
+		Trace:    trace,
+	}
+}
+
+// attempt performs a single generate → transform → validate → parse schema pass,
+// hedging the request if configured. The returned record holds the raw output
+// alongside any transformation, validation, or schema error so that it can be
+// referenced by a repair prompt.
+func (g *Guard) attempt(ctx context.Context, attempt int, messages []Message) (AttemptRecord, string, interface{}) {
+	if g.hedgeBudget != nil {
+		return g.hedgedAttempt(ctx, attempt, messages)
 	}
+	return g.try(ctx, attempt, messages)
 }
 
-// runDetectors runs all detectors in sequence.
-// Returns a DetectionError on the first failure.
+// try performs one request of an attempt and processes its output.
+func (g *Guard) try(ctx context.Context, attempt int, messages []Message) (record AttemptRecord, transformed string, parsed interface{}) {
+	record.Attempt = attempt
+
+	// Generate
+	g.observer.OnGenerationStart(ctx, GenerationStartEvent{Attempt: attempt, Messages: messages})
+	start := time.Now()
+	output, err := g.generate(ctx, messages)
+	record.GenerationLatency = time.Since(start)
+	g.observer.OnGenerationEnd(ctx, GenerationEndEvent{
+		Attempt:  attempt,
+		Duration: record.GenerationLatency,
+		Output:   output,
+		Err:      err,
+	})
+	if err != nil {
+		record.Err = &GenerationError{Err: err}
+		record.Stage = StageGeneration
+		return record, "", nil
+	}
+
+	record.Raw = output
+	transformed, parsed, record.JSONFixes, err = g.process(ctx, attempt, output)
+	if err != nil {
+		record.Err = err
+		record.Stage = stageOf(err)
+		return record, "", nil
+	}
+	return record, transformed, parsed
+}
+
+// generate calls the chat client, applying the per-attempt timeout if configured.
+func (g *Guard) generate(ctx context.Context, messages []Message) (string, error) {
+	if g.attemptTimeout <= 0 {
+		return g.chat.Chat(ctx, messages)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, g.attemptTimeout)
+	defer cancel()
+	attemptCtx = context.WithValue(attemptCtx, callTimeoutKey{}, callTimeout{parent: ctx, timeout: g.attemptTimeout})
+
+	output, err := g.chat.Chat(attemptCtx, messages)
+	if err != nil {
+		return "", g.attemptError(ctx, attemptCtx, err)
+	}
+	return output, nil
+}
+
+// attemptError returns ErrAttemptTimeout in place of a deadline error if the
+// attempt context expired while the run context is still alive, so that the
+// retry loop can tell it apart from the overall deadline. Other errors, such
+// as those of a FallbackClient whose backup failed after the primary timed
+// out, are returned unchanged.
+func (g *Guard) attemptError(ctx, attemptCtx context.Context, err error) error {
+	if ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %v", ErrAttemptTimeout, g.attemptTimeout)
+	}
+	return err
+}
+
+// process runs the transform → validate → parse schema stages on a raw output.
+// It also returns the fixes applied if the schema repaired the output.
+func (g *Guard) process(ctx context.Context, attempt int, output string) (transformed string, parsed interface{}, fixes []JSONFix, err error) {
+	// Transform
+	transformed, err = g.runTransformers(ctx, attempt, output)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Validate
+	if err = g.runValidators(ctx, attempt, transformed); err != nil {
+		return transformed, nil, nil, err
+	}
+
+	// Parse schema
+	parsed, fixes, err = g.parseSchema(ctx, attempt, transformed)
+	if err != nil {
+		return transformed, nil, fixes, err
+	}
+
+	return transformed, parsed, fixes, nil
+}
+
+// runDetectors runs all detectors, in sequence or concurrently depending on
+// the configuration. Returns a DetectionError on the first failure, or a
+// MultiError listing every failure when collect-all mode is enabled.
 func (g *Guard) runDetectors(ctx context.Context, prompt string) error {
+	if g.concurrentDetectors && len(g.detectors) > 1 {
+		return g.runDetectorsConcurrently(ctx, prompt)
+	}
+
+	var errs []error
 	for _, detector := range g.detectors {
-		if err := detector.Detect(ctx, prompt); err != nil {
-			return &DetectionError{
-				Detector: detector.Name(),
-				Err:      err,
+		if err := g.detect(ctx, detector, prompt); err != nil {
+			if !g.collectAll {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return collectErrors(errs)
+}
+
+// runDetectorsConcurrently runs all detectors in parallel. In fail-fast mode
+// the remaining detectors are canceled as soon as one rejects the prompt. In
+// collect-all mode every detector runs to completion and failures are
+// reported in the order the detectors were configured.
+func (g *Guard) runDetectorsConcurrently(ctx context.Context, prompt string) error {
+	detectCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type detectResult struct {
+		index int
+		event DetectorEvent
+	}
+
+	// Buffered so that detectors finishing after an early return do not block
+	results := make(chan detectResult, len(g.detectors))
+	for i, detector := range g.detectors {
+		go func(i int, detector Detector) {
+			results <- detectResult{index: i, event: g.runDetector(detectCtx, detector, prompt)}
+		}(i, detector)
+	}
+
+	// Events are reported here rather than by the detectors, so that
+	// detectors canceled by an early return do not report their cancellation
+	// as a failure after the run moved on
+	errs := make([]error, len(g.detectors))
+	for range g.detectors {
+		res := <-results
+		g.observer.OnDetector(ctx, res.event)
+		if res.event.Err == nil {
+			continue
+		}
+		if !g.collectAll {
+			return res.event.Err
+		}
+		errs[res.index] = res.event.Err
+	}
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return collectErrors(failed)
+}
+
+// detect runs a single detector with the configured detector timeout and
+// reports it to the observers.
+// Returns a DetectionError if the detector rejects the prompt.
+func (g *Guard) detect(ctx context.Context, detector Detector, prompt string) error {
+	event := g.runDetector(ctx, detector, prompt)
+	g.observer.OnDetector(ctx, event)
+	return event.Err
+}
+
+// runDetector runs a single detector with the configured detector timeout.
+// The returned event holds a DetectionError if the detector rejects the prompt.
+func (g *Guard) runDetector(ctx context.Context, detector Detector, prompt string) DetectorEvent {
+	start := time.Now()
+	err := detectWithTimeout(ctx, detector, prompt, g.detectorTimeout, g.detectorTimeoutPolicy)
+	if err != nil {
+		err = &DetectionError{
+			Detector: detector.Name(),
+			Err:      err,
+		}
+	}
+	return DetectorEvent{
+		Detector: detector.Name(),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+}
+
+// runTransformers runs all transformers in sequence, feeding each one the
+// output of the previous. Returns a TransformError on the first failure.
+func (g *Guard) runTransformers(ctx context.Context, attempt int, output string) (string, error) {
+	for _, transformer := range g.transformers {
+		start := time.Now()
+		transformed, err := transformer.Transform(ctx, output)
+		if err != nil {
+			err = &TransformError{
+				Transformer: transformer.Name(),
+				Err:         err,
 			}
 		}
+		g.observer.OnTransformer(ctx, TransformerEvent{
+			Attempt:     attempt,
+			Transformer: transformer.Name(),
+			Duration:    time.Since(start),
+			Err:         err,
+		})
+		if err != nil {
+			return "", err
+		}
+		output = transformed
 	}
-	return nil
+	return output, nil
 }
 
 // runValidators runs all validators in sequence.
-// Returns a ValidationError on the first failure.
-func (g *Guard) runValidators(ctx context.Context, output string) error {
+// Returns a ValidationError on the first failure, or a MultiError listing
+// every failure when collect-all mode is enabled.
+func (g *Guard) runValidators(ctx context.Context, attempt int, output string) error {
+	var errs []error
 	for _, validator := range g.validators {
-		if err := validator.Validate(ctx, output); err != nil {
-			return &ValidationError{
+		start := time.Now()
+		err := validator.Validate(ctx, output)
+		if err != nil {
+			err = &ValidationError{
 				Validator: validator.Name(),
 				Err:       err,
 			}
 		}
+		g.observer.OnValidator(ctx, ValidatorEvent{
+			Attempt:   attempt,
+			Validator: validator.Name(),
+			Duration:  time.Since(start),
+			Err:       err,
+		})
+		if err != nil {
+			if !g.collectAll {
+				return err
+			}
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return collectErrors(errs)
 }
 
-// parseSchema parses the output using the configured schema.
-// Returns nil, nil if no schema is configured.
-func (g *Guard) parseSchema(output string) (interface{}, error) {
+// collectErrors returns nil for no errors, or a MultiError containing them.
+func collectErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: errs}
+}
+
+// parseSchema parses the output using the configured schema, returning the
+// fixes applied if the schema repaired the output.
+// Returns nil, nil, nil if no schema is configured, or a SchemaError if parsing fails.
+func (g *Guard) parseSchema(ctx context.Context, attempt int, output string) (interface{}, []JSONFix, error) {
 	if g.schema == nil {
-		return nil, nil
+		return nil, nil, nil
+	}
+
+	start := time.Now()
+	parsed, fixes, err := g.schema.unmarshal([]byte(output))
+	var schemaErr *SchemaError
+	if err != nil && !errors.As(err, &schemaErr) {
+		err = &SchemaError{Err: err}
 	}
-	return g.schema.Unmarshal([]byte(output))
+	g.observer.OnSchema(ctx, SchemaEvent{
+		Attempt:   attempt,
+		Duration:  time.Since(start),
+		Err:       err,
+		JSONFixes: fixes,
+	})
+	return parsed, fixes, err
 }
 
 // Client returns the configured client.
@@ -203,6 +616,11 @@ func (g *Guard) Client() Client {
 	return g.client
 }
 
+// ChatClient returns the chat client used for generation.
+func (g *Guard) ChatClient() ChatClient {
+	return g.chat
+}
+
 // Schema returns the configured schema, or nil if not set.
 func (g *Guard) Schema() *Schema {
 	return g.schema
@@ -218,6 +636,16 @@ func (g *Guard) Detectors() []Detector {
 	return result
 }
 
+// Transformers returns a copy of the configured transformers.
+func (g *Guard) Transformers() []Transformer {
+	if g.transformers == nil {
+		return nil
+	}
+	result := make([]Transformer, len(g.transformers))
+	copy(result, g.transformers)
+	return result
+}
+
 // Validators returns a copy of the configured validators.
 func (g *Guard) Validators() []Validator {
 	if g.validators == nil {
@@ -0,0 +1,99 @@
+package railguard
+
+import (
+	"strings"
+)
+
+// RepairRequest describes a failed attempt for which a repair prompt is built.
+type RepairRequest struct {
+	// Prompt is the original prompt passed to Run. For RunMessages it is the
+	// content of the last user message.
+	Prompt string
+
+	// Output is the raw output of the failed attempt.
+	Output string
+
+	// Err is the error that rejected the output. It is a TransformError,
+	// ValidationError, SchemaError, or a MultiError of ValidationErrors.
+	Err error
+
+	// Attempt is the number of the failed attempt (1 = first attempt).
+	Attempt int
+}
+
+// RepairPrompter builds follow-up prompts for repair retries.
+// When repair is enabled, a retry after a rejected output sends the prompt
+// returned by RepairPrompt instead of resending the original prompt, so the
+// model can see what went wrong and correct it.
+type RepairPrompter interface {
+	// RepairPrompt returns the prompt to send for the next attempt.
+	RepairPrompt(req RepairRequest) string
+}
+
+// RepairPrompterFunc is an adapter that allows ordinary functions to be used as RepairPrompters.
+type RepairPrompterFunc func(req RepairRequest) string
+
+// RepairPrompt implements the RepairPrompter interface by calling the function itself.
+func (f RepairPrompterFunc) RepairPrompt(req RepairRequest) string {
+	return f(req)
+}
+
+// DefaultRepairPrompter returns a RepairPrompter that appends the previous
+// output and the reason it was rejected to the original prompt.
+func DefaultRepairPrompter() RepairPrompter {
+	return RepairPrompterFunc(defaultRepairPrompt)
+}
+
+// defaultRepairPrompt builds the repair prompt used by DefaultRepairPrompter.
+func defaultRepairPrompt(req RepairRequest) string {
+	var sb strings.Builder
+
+	sb.WriteString(req.Prompt)
+	sb.WriteString("\n\nYour previous response was rejected for the following reason:\n")
+	sb.WriteString(req.Err.Error())
+	sb.WriteString("\n\nPrevious response:\n")
+	sb.WriteString(req.Output)
+	sb.WriteString("\n\nRespond to the original request again, correcting the problem above.")
+
+	return sb.String()
+}
+
+// repairMessages returns a copy of the original conversation in which the
+// last user message is replaced by the repair prompt. If the conversation has
+// no user message, the repair prompt is appended as one.
+func repairMessages(messages []Message, prompter RepairPrompter, req RepairRequest) []Message {
+	last := -1
+	for i, m := range messages {
+		if m.Role == RoleUser {
+			last = i
+		}
+	}
+
+	repaired := make([]Message, len(messages), len(messages)+1)
+	copy(repaired, messages)
+	if last == -1 {
+		return append(repaired, Message{Role: RoleUser, Content: prompter.RepairPrompt(req)})
+	}
+
+	req.Prompt = messages[last].Content
+	repaired[last].Content = prompter.RepairPrompt(req)
+	return repaired
+}
+
+// isRepairable reports whether an error rejected an output that a repair
+// prompt can refer to. Generation errors have no output and are not repairable.
+func isRepairable(err error) bool {
+	switch e := err.(type) {
+	case *TransformError, *ValidationError, *SchemaError:
+		return true
+	case *MultiError:
+		for _, inner := range e.Errors {
+			if !isRepairable(inner) {
+				return false
+			}
+		}
+		return len(e.Errors) > 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,187 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/validators"
+)
+
+func TestDefaultRepairPrompter(t *testing.T) {
+	prompt := railguard.DefaultRepairPrompter().RepairPrompt(railguard.RepairRequest{
+		Prompt:  "Summarize the report",
+		Output:  "a very long summary",
+		Err:     &railguard.ValidationError{Validator: "maxlength", Err: errors.New("output length 12000 bytes exceeds limit of 10000")},
+		Attempt: 1,
+	})
+
+	for _, want := range []string{
+		"Summarize the report",
+		"a very long summary",
+		"output length 12000 bytes exceeds limit of 10000",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected repair prompt to contain %q, got %q", want, prompt)
+		}
+	}
+}
+
+func TestRepairRetries(t *testing.T) {
+	type Response struct {
+		Message string `json:"message"`
+	}
+
+	t.Run("feeds schema error back to the model", func(t *testing.T) {
+		var prompts []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			if len(prompts) == 1 {
+				return `{"message": "hi", "foo": 1}`, nil
+			}
+			return `{"message": "hi"}`, nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(&Response{}),
+			railguard.WithRepair(),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 3, Multiplier: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "say hi")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(prompts) != 2 {
+			t.Fatalf("expected 2 prompts, got %d", len(prompts))
+		}
+		if prompts[0] != "say hi" {
+			t.Errorf("first attempt should send the original prompt, got %q", prompts[0])
+		}
+		if !strings.Contains(prompts[1], "/foo: unknown field") {
+			t.Errorf("repair prompt should describe the schema error, got %q", prompts[1])
+		}
+		if !strings.Contains(prompts[1], `{"message": "hi", "foo": 1}`) {
+			t.Errorf("repair prompt should contain the previous output, got %q", prompts[1])
+		}
+		if !reflect.DeepEqual(result.Metadata.RepairAttempts, []int{2}) {
+			t.Errorf("expected repair attempts [2], got %v", result.Metadata.RepairAttempts)
+		}
+	})
+
+	t.Run("feeds validator error back to the model", func(t *testing.T) {
+		var prompts []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			if len(prompts) == 1 {
+				return "this output is too long", nil
+			}
+			return "short", nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithValidators(validators.NewMaxLength(10)),
+			railguard.WithRepair(),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(prompts[1], "exceeds limit of 10") {
+			t.Errorf("repair prompt should describe the validation error, got %q", prompts[1])
+		}
+	})
+
+	t.Run("generation errors resend the previous prompt", func(t *testing.T) {
+		var prompts []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			switch len(prompts) {
+			case 1:
+				return "bad", nil
+			case 2:
+				return "", errors.New("temporary failure")
+			default:
+				return "good", nil
+			}
+		})
+
+		prompter := railguard.RepairPrompterFunc(func(req railguard.RepairRequest) string {
+			return "repair: " + req.Output
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if output == "bad" {
+					return errors.New("bad output")
+				}
+				return nil
+			})),
+			railguard.WithRepairPrompter(prompter),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 3, Multiplier: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []string{"test", "repair: bad", "repair: bad"}
+		if !reflect.DeepEqual(prompts, want) {
+			t.Errorf("expected prompts %q, got %q", want, prompts)
+		}
+		if !reflect.DeepEqual(result.Metadata.RepairAttempts, []int{2, 3}) {
+			t.Errorf("expected repair attempts [2 3], got %v", result.Metadata.RepairAttempts)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		var prompts []string
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return "{}", nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithValidators(railguard.ValidatorFunc(func(ctx context.Context, output string) error {
+				if len(prompts) == 1 {
+					return errors.New("rejected")
+				}
+				return nil
+			})),
+			railguard.WithRetry(railguard.RetryConfig{MaxAttempts: 2, Multiplier: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if prompts[1] != "test" {
+			t.Errorf("expected identical prompt without repair, got %q", prompts[1])
+		}
+		if len(result.Metadata.RepairAttempts) != 0 {
+			t.Errorf("expected no repair attempts, got %v", result.Metadata.RepairAttempts)
+		}
+	})
+}
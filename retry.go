@@ -82,22 +82,101 @@ func (c RetryConfig) delay(attempt int) time.Duration {
 	return time.Duration(delay)
 }
 
-// backoff sleeps for the appropriate delay for the given attempt.
+// sleep waits for the given delay.
 // Returns immediately if the context is canceled.
-func (c RetryConfig) backoff(ctx context.Context, attempt int) error {
-	delay := c.delay(attempt)
-	if delay == 0 {
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
 		return nil
 	}
 
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(delay):
+	case <-timer.C:
 		return nil
 	}
 }
 
+// NoDeadline is passed to a RetryPolicy as the remaining time when the
+// context has no deadline.
+const NoDeadline time.Duration = math.MaxInt64
+
+// RetryPolicy decides whether a failed attempt is retried and how long to
+// wait before the next attempt. The number of attempts is still limited by
+// RetryConfig.MaxAttempts.
+type RetryPolicy interface {
+	// Retry is called after a failed attempt with the error that failed it,
+	// the attempt number (1 = first attempt), and the time remaining before
+	// the context deadline (NoDeadline if there is none). It returns whether
+	// to retry and the delay before the next attempt.
+	Retry(err error, attempt int, remaining time.Duration) (retry bool, delay time.Duration)
+}
+
+// RetryPolicyFunc is an adapter that allows ordinary functions to be used as RetryPolicies.
+type RetryPolicyFunc func(err error, attempt int, remaining time.Duration) (bool, time.Duration)
+
+// Retry implements the RetryPolicy interface by calling the function itself.
+func (f RetryPolicyFunc) Retry(err error, attempt int, remaining time.Duration) (bool, time.Duration) {
+	return f(err, attempt, remaining)
+}
+
+// TemporaryError is implemented by client errors that declare whether they
+// are worth retrying. Errors whose Temporary method returns false, such as
+// authentication failures or invalid requests, are not retried by
+// DefaultRetryPolicy.
+type TemporaryError interface {
+	error
+	Temporary() bool
+}
+
+// RetryAfterError is implemented by client errors that carry a server hint
+// for how long to wait before retrying, such as the Retry-After header of a
+// 429 response. DefaultRetryPolicy waits for the hinted delay instead of the
+// configured backoff.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used unless WithRetryPolicy is
+// given. It never retries detection errors, context errors, ErrCircuitOpen,
+// or errors that implement TemporaryError and report false. It waits for the
+// full delay of a RetryAfterError if present, and otherwise for the
+// exponential backoff described by config. A hinted delay longer than
+// config.MaxDelay is not retried, since retrying earlier than the server asked
+// is sure to fail again.
+func DefaultRetryPolicy(config RetryConfig) RetryPolicy {
+	return RetryPolicyFunc(func(err error, attempt int, remaining time.Duration) (bool, time.Duration) {
+		if !shouldRetry(err) {
+			return false, 0
+		}
+
+		// A hint longer than the remaining time makes the retry loop give
+		// up with ErrInsufficientTime
+		var hinted RetryAfterError
+		if errors.As(err, &hinted) && hinted.RetryAfter() > 0 {
+			if hinted.RetryAfter() > config.MaxDelay {
+				return false, 0
+			}
+			return true, hinted.RetryAfter()
+		}
+		return true, config.delay(attempt)
+	})
+}
+
+// remainingTime returns the time left before the context deadline,
+// or NoDeadline if the context has none.
+func remainingTime(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return NoDeadline
+	}
+	return time.Until(deadline)
+}
+
 // retryClassifier determines whether an error is retryable.
 type retryClassifier struct{}
 
@@ -106,11 +185,13 @@ type retryClassifier struct{}
 var fatalErrors = []error{
 	context.Canceled,
 	context.DeadlineExceeded,
+	ErrCircuitOpen,
 }
 
 // shouldRetry determines if an error is retryable.
-// Detection errors and context errors are never retried.
-// Generation and validation errors are generally retryable.
+// Detection errors, context errors, open circuit breakers, and errors that
+// declare themselves permanent are never retried. Generation and validation errors are
+// generally retryable.
 func shouldRetry(err error) bool {
 	if err == nil {
 		return false
@@ -122,15 +203,38 @@ func shouldRetry(err error) bool {
 		return false
 	}
 
-	// Context errors are never retried
+	// A fallback that tried several clients is worth retrying if any of
+	// them is, e.g. when the primary is down and the backup is overloaded
+	var fallbackErr *FallbackError
+	if errors.As(err, &fallbackErr) {
+		for _, clientErr := range fallbackErr.Errors {
+			if shouldRetry(clientErr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Context errors and open circuit breakers are never retried
 	for _, fatalErr := range fatalErrors {
 		if errors.Is(err, fatalErr) {
 			return false
 		}
 	}
 
+	if permanent(err) {
+		return false
+	}
+
 	// Generation and validation errors are generally retryable
 	// as LLM outputs are non-deterministic
 	return true
 }
 
+// permanent reports whether err is a client error that declares itself
+// permanent, e.g. a 401 or an invalid model name.
+func permanent(err error) bool {
+	var temporary TemporaryError
+	return errors.As(err, &temporary) && !temporary.Temporary()
+}
+
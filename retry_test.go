@@ -1,6 +1,9 @@
 package railguard_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -138,3 +141,268 @@ func TestRetryConfigValidate(t *testing.T) {
 	}
 }
 
+
+// statusError is a client error that declares whether it is retryable
+// and optionally carries a Retry-After hint.
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string             { return fmt.Sprintf("status %d", e.code) }
+func (e *statusError) Temporary() bool           { return e.code == 429 || e.code >= 500 }
+func (e *statusError) RetryAfter() time.Duration { return e.retryAfter }
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	config := railguard.RetryConfig{
+		MaxAttempts:  3,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   2,
+	}
+	policy := railguard.DefaultRetryPolicy(config)
+
+	tests := []struct {
+		name      string
+		err       error
+		attempt   int
+		remaining time.Duration
+		wantRetry bool
+		wantDelay time.Duration
+	}{
+		{
+			name:      "validation error uses backoff",
+			err:       &railguard.ValidationError{Validator: "json", Err: errors.New("invalid")},
+			attempt:   1,
+			wantRetry: true,
+			wantDelay: 100 * time.Millisecond,
+		},
+		{
+			name:      "backoff grows with attempts",
+			err:       &railguard.SchemaError{Err: errors.New("missing field")},
+			attempt:   2,
+			wantRetry: true,
+			wantDelay: 200 * time.Millisecond,
+		},
+		{
+			name:      "detection error",
+			err:       &railguard.DetectionError{Detector: "keywords", Err: errors.New("blocked")},
+			attempt:   1,
+			wantRetry: false,
+		},
+		{
+			name:      "context canceled",
+			err:       &railguard.GenerationError{Err: context.Canceled},
+			attempt:   1,
+			wantRetry: false,
+		},
+		{
+			name:      "permanent client error",
+			err:       &railguard.GenerationError{Err: &statusError{code: 401}},
+			attempt:   1,
+			wantRetry: false,
+		},
+		{
+			name:      "temporary client error uses backoff",
+			err:       &railguard.GenerationError{Err: &statusError{code: 503}},
+			attempt:   1,
+			wantRetry: true,
+			wantDelay: 100 * time.Millisecond,
+		},
+		{
+			name:      "retry after hint",
+			err:       &railguard.GenerationError{Err: &statusError{code: 429, retryAfter: 500 * time.Millisecond}},
+			attempt:   1,
+			wantRetry: true,
+			wantDelay: 500 * time.Millisecond,
+		},
+		{
+			name:      "retry after hint at max delay",
+			err:       &railguard.GenerationError{Err: &statusError{code: 429, retryAfter: time.Second}},
+			attempt:   1,
+			wantRetry: true,
+			wantDelay: time.Second,
+		},
+		{
+			name:      "retry after hint longer than max delay",
+			err:       &railguard.GenerationError{Err: &statusError{code: 429, retryAfter: time.Minute}},
+			attempt:   1,
+			wantRetry: false,
+		},
+		{
+			name:      "retry after hint longer than remaining time is not shortened",
+			err:       &railguard.GenerationError{Err: &statusError{code: 429, retryAfter: 800 * time.Millisecond}},
+			attempt:   1,
+			remaining: 300 * time.Millisecond,
+			wantRetry: true,
+			wantDelay: 800 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := tt.remaining
+			if remaining == 0 {
+				remaining = railguard.NoDeadline
+			}
+			retry, delay := policy.Retry(tt.err, tt.attempt, remaining)
+			if retry != tt.wantRetry {
+				t.Errorf("expected retry %v, got %v", tt.wantRetry, retry)
+			}
+			if retry && delay != tt.wantDelay {
+				t.Errorf("expected delay %v, got %v", tt.wantDelay, delay)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDeadline(t *testing.T) {
+	calls := 0
+	client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return "", &statusError{code: 429, retryAfter: time.Hour}
+	})
+	g, err := railguard.New(
+		railguard.WithClient(client),
+		railguard.WithRetry(railguard.RetryConfig{
+			MaxAttempts:  3,
+			InitialDelay: time.Millisecond,
+			MaxDelay:     time.Hour,
+			Multiplier:   1,
+		}),
+		railguard.WithTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	start := time.Now()
+	_, err = g.Run(context.Background(), "test")
+	if !errors.Is(err, railguard.ErrInsufficientTime) {
+		t.Errorf("expected ErrInsufficientTime, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected to fail fast, took %v", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestRetryAfterLongerThanMaxDelay(t *testing.T) {
+	calls := 0
+	client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return "", &statusError{code: 429, retryAfter: time.Minute}
+	})
+	g, err := railguard.New(railguard.WithClient(client), railguard.WithMaxRetries(3))
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	start := time.Now()
+	_, err = g.Run(context.Background(), "test")
+	var status *statusError
+	if !errors.As(err, &status) || status.code != 429 {
+		t.Errorf("expected the 429 error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected to stop without waiting, took %v", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestWithRetryPolicyRun(t *testing.T) {
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		calls := 0
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			calls++
+			return "", &statusError{code: 400}
+		})
+
+		g, err := railguard.New(railguard.WithClient(client), railguard.WithMaxRetries(3))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Run(context.Background(), "test")
+		var genErr *railguard.GenerationError
+		if !errors.As(err, &genErr) {
+			t.Fatalf("expected GenerationError, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("custom policy controls retries and delay", func(t *testing.T) {
+		calls := 0
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			calls++
+			if calls < 3 {
+				return "", errors.New("overloaded")
+			}
+			return "ok", nil
+		})
+
+		var remaining []time.Duration
+		policy := railguard.RetryPolicyFunc(func(err error, attempt int, left time.Duration) (bool, time.Duration) {
+			remaining = append(remaining, left)
+			return true, time.Duration(attempt) * time.Millisecond
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithMaxRetries(5),
+			railguard.WithRetryPolicy(policy),
+			railguard.WithTimeout(time.Minute),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Metadata.Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", result.Metadata.Attempts)
+		}
+		if got := result.Metadata.Trace[2].Backoff; got != 2*time.Millisecond {
+			t.Errorf("expected policy delay before third attempt, got %v", got)
+		}
+		for _, left := range remaining {
+			if left <= 0 || left > time.Minute {
+				t.Errorf("expected remaining time within the run timeout, got %v", left)
+			}
+		}
+	})
+
+	t.Run("policy can stop retries", func(t *testing.T) {
+		calls := 0
+		client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			calls++
+			return "", errors.New("failed")
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithMaxRetries(5),
+			railguard.WithRetryPolicy(railguard.RetryPolicyFunc(func(err error, attempt int, remaining time.Duration) (bool, time.Duration) {
+				return false, 0
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := g.Run(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package railguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runStateKey is the context key for the state of the current run.
+type runStateKey struct{}
+
+// callTimeoutKey is the context key for the callTimeout of a generation call.
+type callTimeoutKey struct{}
+
+// callTimeout describes the per-attempt timeout applied to a generation call,
+// so that a FallbackClient can give each of its clients the full timeout
+// instead of sharing one deadline. parent is the run context the timeout was
+// applied to; the caller only gave up once it is done.
+type callTimeout struct {
+	parent  context.Context
+	timeout time.Duration
+}
+
+// runState carries per-run information between the Guard and the clients
+// taking part in a run, such as FallbackClient. It is stored in the context
+// passed to the client, so clients shared between runs stay stateless.
+type runState struct {
+	mu sync.Mutex
+
+	// client is the name of the client that produced the latest output.
+	client string
+
+	// stopReason is the stop reason reported for the latest output.
+	stopReason string
+
+	// clientAttempts counts the calls made to each named client.
+	clientAttempts map[string]int
+
+	// rejections counts the outputs of each named client that were rejected
+	// by a transformer, validator, or the schema.
+	rejections map[string]int
+}
+
+// withRunState returns a copy of ctx carrying a new run state.
+func withRunState(ctx context.Context) (context.Context, *runState) {
+	state := &runState{
+		clientAttempts: make(map[string]int),
+		rejections:     make(map[string]int),
+	}
+	return context.WithValue(ctx, runStateKey{}, state), state
+}
+
+// runStateFrom returns the run state stored in ctx, or nil outside a run.
+func runStateFrom(ctx context.Context) *runState {
+	state, _ := ctx.Value(runStateKey{}).(*runState)
+	return state
+}
+
+// SetStopReason reports why the provider stopped generating the output of
+// the current call, e.g. "end_turn" or "max_tokens". Clients call it before
+// returning; the Guard records it in AttemptRecord.StopReason and
+// Metadata.StopReason. It does nothing outside a Guard run.
+func SetStopReason(ctx context.Context, reason string) {
+	if state := runStateFrom(ctx); state != nil {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.stopReason = reason
+	}
+}
+
+// fork returns the state of one request of a hedged attempt. The request
+// sees the rejections recorded so far but tracks its own client, stop reason,
+// and calls until its state is merged back with merge.
+func (s *runState) fork() *runState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	child := &runState{
+		clientAttempts: make(map[string]int),
+		rejections:     make(map[string]int, len(s.rejections)),
+	}
+	for name, n := range s.rejections {
+		child.rejections[name] = n
+	}
+	return child
+}
+
+// merge records the client, stop reason, and calls of a forked state as the
+// outcome of the current attempt.
+func (s *runState) merge(child *runState) {
+	child.mu.Lock()
+	defer child.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.client = child.client
+	s.stopReason = child.stopReason
+	for name, n := range child.clientAttempts {
+		s.clientAttempts[name] += n
+	}
+}
+
+// beginAttempt forgets the client and stop reason of the previous attempt.
+func (s *runState) beginAttempt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = ""
+	s.stopReason = ""
+}
+
+// recordCall counts a call to the named client.
+func (s *runState) recordCall(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientAttempts[name]++
+}
+
+// recordOutput records the client that produced the latest output.
+func (s *runState) recordOutput(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = name
+}
+
+// recordRejection counts a rejected output against the client that produced it.
+func (s *runState) recordRejection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != "" {
+		s.rejections[s.client]++
+	}
+}
+
+// rejectionsOf returns the number of rejected outputs of the named client.
+func (s *runState) rejectionsOf(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejections[name]
+}
+
+// lastClient returns the name of the client that produced the latest output.
+func (s *runState) lastClient() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// lastStopReason returns the stop reason reported for the latest output.
+func (s *runState) lastStopReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopReason
+}
+
+// attempts returns a copy of the per-client call counts, or nil if no named
+// client took part in the run.
+func (s *runState) attempts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.clientAttempts) == 0 {
+		return nil
+	}
+	attempts := make(map[string]int, len(s.clientAttempts))
+	for name, n := range s.clientAttempts {
+		attempts[name] = n
+	}
+	return attempts
+}
@@ -1,8 +1,6 @@
 package railguard
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -11,8 +9,10 @@ import (
 // It validates that LLM output conforms to an expected structure,
 // preventing hallucinated fields and ensuring type safety.
 type Schema struct {
-	targetType reflect.Type
-	strict     bool
+	targetType     reflect.Type
+	strict         bool
+	requiredFields bool
+	lenient        bool
 }
 
 // NewSchema creates a Schema from a struct pointer.
@@ -39,9 +39,14 @@ func NewSchema(v interface{}) (*Schema, error) {
 		return nil, fmt.Errorf("%w: got pointer to %v, want struct", ErrInvalidSchema, elem.Kind())
 	}
 
+	if err := validateConstraintTags(elem, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+
 	return &Schema{
-		targetType: elem,
-		strict:     true, // Default to strict to prevent hallucinated fields
+		targetType:     elem,
+		strict:         true, // Default to strict to prevent hallucinated fields
+		requiredFields: true, // Default to requiring fields so "{}" does not parse
 	}, nil
 }
 
@@ -57,6 +62,36 @@ func (s *Schema) IsStrict() bool {
 	return s.strict
 }
 
+// WithRequiredFields sets whether fields are required by default.
+// When enabled (the default), a field that is not a pointer and has no
+// omitempty option must be present in the JSON and not null, in nested
+// structs as well. Fields can override this with `railguard:"optional"`
+// or `railguard:"required"`, which apply regardless of this setting.
+func (s *Schema) WithRequiredFields(required bool) *Schema {
+	s.requiredFields = required
+	return s
+}
+
+// RequiresFields returns whether fields are required by default.
+func (s *Schema) RequiresFields() bool {
+	return s.requiredFields
+}
+
+// WithLenient sets whether the schema repairs almost-JSON, such as trailing
+// commas, single quotes, or an object truncated at the token limit, before
+// decoding; see RepairJSON for the defects fixed. Repair is only attempted
+// when the output is not valid JSON, so valid JSON is never changed.
+// By default, lenient mode is disabled.
+func (s *Schema) WithLenient(lenient bool) *Schema {
+	s.lenient = lenient
+	return s
+}
+
+// IsLenient returns whether the schema repairs almost-JSON before decoding.
+func (s *Schema) IsLenient() bool {
+	return s.lenient
+}
+
 // TargetType returns the reflect.Type that this schema validates against.
 func (s *Schema) TargetType() reflect.Type {
 	return s.targetType
@@ -64,28 +99,21 @@ func (s *Schema) TargetType() reflect.Type {
 
 // Unmarshal parses JSON data into a new instance of the schema's target type.
 // In strict mode (default), unknown fields in the JSON will cause an error.
-// Returns a pointer to the populated struct or an error if parsing fails.
+// Returns a pointer to the populated struct, or a *SchemaError listing every
+// issue found if parsing fails.
 func (s *Schema) Unmarshal(data []byte) (interface{}, error) {
-	// Create a new instance of the target type
-	ptr := reflect.New(s.targetType)
-
-	// Set up decoder with optional strict mode
-	dec := json.NewDecoder(bytes.NewReader(data))
-	if s.strict {
-		dec.DisallowUnknownFields()
-	}
-
-	// Decode the JSON
-	if err := dec.Decode(ptr.Interface()); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
-	}
+	parsed, _, err := s.unmarshal(data)
+	return parsed, err
+}
 
-	// Check for trailing data after the JSON value
-	if dec.More() {
-		return nil, fmt.Errorf("trailing data after JSON")
+// unmarshal is Unmarshal, also returning the fixes applied in lenient mode.
+func (s *Schema) unmarshal(data []byte) (interface{}, []JSONFix, error) {
+	ptr := reflect.New(s.targetType)
+	fixes, err := s.repairAndDecode(data, ptr)
+	if err != nil {
+		return nil, fixes, err
 	}
-
-	return ptr.Interface(), nil
+	return ptr.Interface(), fixes, nil
 }
 
 // UnmarshalInto parses JSON data into the provided destination.
@@ -105,20 +133,24 @@ func (s *Schema) UnmarshalInto(data []byte, dest interface{}) error {
 		return fmt.Errorf("destination type mismatch: got %v, want %v", destType.Elem(), s.targetType)
 	}
 
-	dec := json.NewDecoder(bytes.NewReader(data))
-	if s.strict {
-		dec.DisallowUnknownFields()
-	}
+	_, err := s.repairAndDecode(data, reflect.ValueOf(dest))
+	return err
+}
 
-	if err := dec.Decode(dest); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+// repairAndDecode decodes data into dest. In lenient mode, output that is
+// not valid JSON is repaired and decoded again; issues found after a repair
+// refer to the repaired JSON. If repair fails, the original error is returned.
+func (s *Schema) repairAndDecode(data []byte, dest reflect.Value) ([]JSONFix, error) {
+	err := s.decode(data, dest)
+	if err == nil || !s.lenient || !isMalformed(err) {
+		return nil, err
 	}
 
-	if dec.More() {
-		return fmt.Errorf("trailing data after JSON")
+	repaired, fixes, repairErr := RepairJSON(data)
+	if repairErr != nil {
+		return nil, err
 	}
-
-	return nil
+	return fixes, s.decode(repaired, dest)
 }
 
 // Validate checks if the provided JSON data can be unmarshaled into the schema's target type.
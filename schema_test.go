@@ -1,7 +1,9 @@
 package railguard_test
 
 import (
+	"encoding/json"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -86,7 +88,7 @@ func TestSchemaUnmarshal(t *testing.T) {
 	})
 
 	t.Run("unknown fields rejected in strict mode", func(t *testing.T) {
-		data := []byte(`{"result": "test", "unknown_field": "value"}`)
+		data := []byte(`{"result": "test", "count": 1, "success": true, "unknown_field": "value"}`)
 		_, err := schema.Unmarshal(data)
 		if err == nil {
 			t.Error("expected error for unknown fields in strict mode")
@@ -121,7 +123,7 @@ func TestSchemaStrictMode(t *testing.T) {
 		}
 
 		// Now unknown fields should be allowed
-		data := []byte(`{"result": "test", "unknown_field": "value"}`)
+		data := []byte(`{"result": "test", "count": 1, "success": true, "unknown_field": "value"}`)
 		_, err := schema.Unmarshal(data)
 		if err != nil {
 			t.Errorf("non-strict mode should allow unknown fields: %v", err)
@@ -136,7 +138,7 @@ func TestSchemaUnmarshalInto(t *testing.T) {
 	}
 
 	t.Run("valid destination", func(t *testing.T) {
-		data := []byte(`{"result": "test", "count": 1}`)
+		data := []byte(`{"result": "test", "count": 1, "success": true}`)
 		var dest testResponse
 		err := schema.UnmarshalInto(data, &dest)
 		if err != nil {
@@ -178,7 +180,7 @@ func TestSchemaValidate(t *testing.T) {
 	}
 
 	t.Run("valid JSON passes", func(t *testing.T) {
-		data := []byte(`{"result": "test"}`)
+		data := []byte(`{"result": "test", "count": 0, "success": false}`)
 		if err := schema.Validate(data); err != nil {
 			t.Errorf("expected validation to pass: %v", err)
 		}
@@ -192,3 +194,180 @@ func TestSchemaValidate(t *testing.T) {
 	})
 }
 
+type issueOrder struct {
+	ID    int         `json:"id"`
+	Email string      `json:"email"`
+	Lines []issueLine `json:"lines"`
+	Note  *string     `json:"note"`
+}
+
+type issueLine struct {
+	SKU    string  `json:"sku" railguard:"pattern=^[A-Z]+$"`
+	Amount float64 `json:"amount" railguard:"min=0"`
+	Count  int8    `json:"count,omitempty"`
+}
+
+func TestSchemaIssues(t *testing.T) {
+	schema, err := railguard.NewSchema(&issueOrder{})
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	issuesOf := func(t *testing.T, input string) []railguard.SchemaIssue {
+		t.Helper()
+		_, err := schema.Unmarshal([]byte(input))
+		var schemaErr *railguard.SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatalf("expected SchemaError, got %v", err)
+		}
+		return schemaErr.Issues
+	}
+
+	t.Run("collects all issues in one pass", func(t *testing.T) {
+		issues := issuesOf(t, `{
+			"id": "7",
+			"lines": [
+				{"sku": "ABC", "amount": 1},
+				{"sku": "abc", "amount": -2, "count": 300},
+				{"sku": "XYZ", "amount": null, "price": 3}
+			],
+			"note": null,
+			"a/b": true
+		}`)
+
+		want := []railguard.SchemaIssue{
+			{Kind: railguard.IssueTypeMismatch, Path: "/id", Expected: "integer", Actual: "string", Message: "expected integer, got string"},
+			{Kind: railguard.IssueMissingRequired, Path: "/email", Expected: "string", Message: "is required"},
+			{Kind: railguard.IssueConstraint, Path: "/lines/1/sku", Constraint: "pattern", Message: `must match pattern ^[A-Z]+$`},
+			{Kind: railguard.IssueConstraint, Path: "/lines/1/amount", Constraint: "min", Message: "must be at least 0, got -2"},
+			{Kind: railguard.IssueTypeMismatch, Path: "/lines/1/count", Expected: "integer", Actual: "integer", Message: "expected integer, got integer out of range"},
+			{Kind: railguard.IssueMissingRequired, Path: "/lines/2/amount", Expected: "number", Actual: "null", Message: "is required"},
+			{Kind: railguard.IssueUnknownField, Path: "/lines/2/price", Actual: "integer", Message: "unknown field"},
+			{Kind: railguard.IssueUnknownField, Path: "/a~1b", Actual: "boolean", Message: "unknown field"},
+		}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues:\ngot:  %+v\nwant: %+v", issues, want)
+		}
+	})
+
+	t.Run("syntax error offset", func(t *testing.T) {
+		issues := issuesOf(t, `{"id": 1,, "email": "a"}`)
+		want := []railguard.SchemaIssue{{Kind: railguard.IssueSyntax, Offset: 10, Message: "invalid character ',' looking for beginning of object key string"}}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues: %+v", issues)
+		}
+	})
+
+	t.Run("truncated output", func(t *testing.T) {
+		input := `{"id": 1, "email": "a`
+		issues := issuesOf(t, input)
+		if len(issues) != 1 || issues[0].Kind != railguard.IssueSyntax || issues[0].Offset != int64(len(input)) {
+			t.Errorf("expected syntax issue at end of input, got %+v", issues)
+		}
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		issues := issuesOf(t, `{"id": 1, "email": "a", "lines": []}  {"id": 2}`)
+		want := []railguard.SchemaIssue{{Kind: railguard.IssueTrailingData, Offset: 38, Message: "trailing data after JSON"}}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues: %+v", issues)
+		}
+	})
+
+	t.Run("wrong root type", func(t *testing.T) {
+		issues := issuesOf(t, `[1, 2]`)
+		want := []railguard.SchemaIssue{{Kind: railguard.IssueTypeMismatch, Expected: "object", Actual: "array", Message: "expected object, got array"}}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues: %+v", issues)
+		}
+	})
+
+	t.Run("null root", func(t *testing.T) {
+		issues := issuesOf(t, `null`)
+		want := []railguard.SchemaIssue{{Kind: railguard.IssueTypeMismatch, Expected: "object", Actual: "null", Message: "expected object, got null"}}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues: %+v", issues)
+		}
+	})
+
+	t.Run("null struct elements", func(t *testing.T) {
+		issues := issuesOf(t, `{"id": 1, "email": "a", "lines": [{"sku": "A", "amount": 1}, null]}`)
+		want := []railguard.SchemaIssue{{Kind: railguard.IssueMissingRequired, Path: "/lines/1", Expected: "object", Actual: "null", Message: "is required"}}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("unexpected issues: %+v", issues)
+		}
+
+		optional, _ := railguard.NewSchema(&issueOrder{})
+		optional.WithRequiredFields(false)
+		if _, err := optional.Unmarshal([]byte(`{"lines": [null]}`)); err != nil {
+			t.Errorf("expected null elements to pass without required fields, got %v", err)
+		}
+	})
+
+	t.Run("error message lists issues", func(t *testing.T) {
+		_, err := schema.Unmarshal([]byte(`{"id": 1, "email": "a", "lines": [], "foo": 1}`))
+		want := "schema validation failed: /foo: unknown field"
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	})
+
+	t.Run("unwraps to the decoding error", func(t *testing.T) {
+		_, err := schema.Unmarshal([]byte(`{"id": "7", "email": "a", "lines": []}`))
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("expected UnmarshalTypeError, got %v", err)
+		}
+	})
+}
+
+func TestSchemaLenient(t *testing.T) {
+	schema, err := railguard.NewSchema(&testResponse{})
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	if schema.IsLenient() {
+		t.Fatal("schema should not be lenient by default")
+	}
+	almost := []byte(`{result: 'ok', count: 2, success: True, /* done */}`)
+
+	t.Run("strict parsing rejects almost-JSON", func(t *testing.T) {
+		if _, err := schema.Unmarshal(almost); err == nil {
+			t.Error("expected error without lenient mode")
+		}
+	})
+
+	schema.WithLenient(true)
+
+	t.Run("repairs almost-JSON", func(t *testing.T) {
+		parsed, err := schema.Unmarshal(almost)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp := parsed.(*testResponse)
+		if resp.Result != "ok" || resp.Count != 2 || !resp.Success {
+			t.Errorf("unexpected result: %+v", resp)
+		}
+
+		var dest testResponse
+		if err := schema.UnmarshalInto(almost, &dest); err != nil || dest.Result != "ok" {
+			t.Errorf("expected UnmarshalInto to repair, got %+v, %v", dest, err)
+		}
+	})
+
+	t.Run("repaired output is still checked", func(t *testing.T) {
+		_, err := schema.Unmarshal([]byte(`{result: 'ok', count: 'two', success: true}`))
+		var schemaErr *railguard.SchemaError
+		if !errors.As(err, &schemaErr) || len(schemaErr.Issues) != 1 || schemaErr.Issues[0].Path != "/count" {
+			t.Errorf("expected type mismatch at /count, got %v", err)
+		}
+	})
+
+	t.Run("unrepairable output keeps the original error", func(t *testing.T) {
+		_, err := schema.Unmarshal([]byte(`{"result": "ok"} trailing`))
+		var schemaErr *railguard.SchemaError
+		if !errors.As(err, &schemaErr) || schemaErr.Issues[0].Kind != railguard.IssueTrailingData {
+			t.Errorf("expected trailing data issue, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,564 @@
+package railguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// decode parses data into dest, a pointer to the schema's target type, and
+// checks it against the schema. Problems are returned as a *SchemaError
+// listing every issue that can be found in one pass: syntax errors and
+// trailing data stop parsing, while unknown fields, type mismatches, missing
+// required fields, and constraint violations are all collected.
+func (s *Schema) decode(data []byte, dest reflect.Value) error {
+	// Parse a generic tree first, which locates syntax errors and keeps
+	// the keys and types encoding/json forgets
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return &SchemaError{
+			Err:    fmt.Errorf("failed to unmarshal JSON: %w", err),
+			Issues: []SchemaIssue{syntaxIssue(err, data)},
+		}
+	}
+	if dec.More() {
+		offset := dec.InputOffset()
+		offset += int64(len(data[offset:]) - len(bytes.TrimLeft(data[offset:], " \t\r\n")))
+		return &SchemaError{
+			Err: fmt.Errorf("trailing data after JSON"),
+			Issues: []SchemaIssue{{
+				Kind:    IssueTrailingData,
+				Offset:  offset,
+				Message: "trailing data after JSON",
+			}},
+		}
+	}
+
+	// encoding/json keeps decoding after a type error, so the value is
+	// complete apart from mismatched fields, which the checker skips
+	decodeErr := json.Unmarshal(data, dest.Interface())
+
+	c := &schemaChecker{strict: s.strict, implicitRequired: s.requiredFields}
+	if raw == nil {
+		// null decodes into anything, but a document must hold the object
+		expected := expectedType(dest.Type(), false)
+		c.issues = append(c.issues, SchemaIssue{
+			Kind:     IssueTypeMismatch,
+			Expected: expected,
+			Actual:   "null",
+			Message:  fmt.Sprintf("expected %s, got null", expected),
+		})
+	}
+	c.walk(dest, dest.Type(), raw, nil)
+
+	if decodeErr != nil && !c.hasKind(IssueTypeMismatch) {
+		// Rejected by a custom unmarshaler, which we cannot locate
+		c.issues = append(c.issues, SchemaIssue{
+			Kind:    IssueTypeMismatch,
+			Message: decodeErr.Error(),
+		})
+	}
+	if len(c.issues) == 0 {
+		return nil
+	}
+
+	schemaErr := &SchemaError{Issues: c.issues}
+	switch {
+	case decodeErr != nil:
+		schemaErr.Err = fmt.Errorf("failed to unmarshal JSON: %w", decodeErr)
+	case len(c.violations) > 0:
+		schemaErr.Err = &ConstraintError{Violations: c.violations}
+	default:
+		schemaErr.Err = fmt.Errorf("failed to unmarshal JSON: %s", c.issues[0].Message)
+	}
+	return schemaErr
+}
+
+// isMalformed reports whether err from decode means the data is not a single
+// JSON value, as opposed to JSON that does not match the schema.
+func isMalformed(err error) bool {
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || len(schemaErr.Issues) != 1 {
+		return false
+	}
+	kind := schemaErr.Issues[0].Kind
+	return kind == IssueSyntax || kind == IssueTrailingData
+}
+
+// syntaxIssue converts an error from parsing data into an issue.
+func syntaxIssue(err error, data []byte) SchemaIssue {
+	issue := SchemaIssue{Kind: IssueSyntax, Message: err.Error()}
+
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		issue.Offset = syntaxErr.Offset
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		issue.Offset = int64(len(data))
+		issue.Message = "unexpected end of JSON input"
+	}
+	return issue
+}
+
+// schemaChecker walks a decoded value together with the JSON it was decoded
+// from, collecting issues and constraint violations.
+type schemaChecker struct {
+	strict           bool
+	implicitRequired bool
+	issues           []SchemaIssue
+	violations       []Violation
+}
+
+// pathSegment is an object key or array index in a path.
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// walk checks the JSON value raw against type t. v is the value raw was
+// decoded into; it is invalid when only the type can be checked, e.g. for
+// keys of maps with non-string keys.
+func (c *schemaChecker) walk(v reflect.Value, t reflect.Type, raw interface{}, path []pathSegment) {
+	if raw == nil {
+		// null decodes into any type; required fields are checked by the parent
+		return
+	}
+	if expected, ok := mismatch(t, raw); !ok {
+		actual := jsonType(raw)
+		c.issues = append(c.issues, SchemaIssue{
+			Kind:     IssueTypeMismatch,
+			Path:     pointerPath(path),
+			Expected: expected,
+			Actual:   actual,
+			Message:  fmt.Sprintf("expected %s, got %s", expected, describe(expected, actual)),
+		})
+		return
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	if decodesItself(t) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		c.walkStruct(v, t, raw.(map[string]interface{}), path)
+
+	case reflect.Slice, reflect.Array:
+		items, _ := raw.([]interface{})
+		for i, item := range items {
+			elemPath := appendPath(path, pathSegment{index: i})
+			elemType := t.Elem()
+			if item == nil && c.implicitRequired && elemType.Kind() == reflect.Struct && !decodesItself(elemType) {
+				// A null element leaves a zero struct, like a null required field
+				c.issues = append(c.issues, SchemaIssue{
+					Kind:     IssueMissingRequired,
+					Path:     pointerPath(elemPath),
+					Expected: expectedType(elemType, false),
+					Actual:   "null",
+					Message:  "is required",
+				})
+				c.violations = append(c.violations, Violation{Path: dottedPath(elemPath), Constraint: "required", Message: "is required"})
+				continue
+			}
+			var elem reflect.Value
+			if v.IsValid() && i < v.Len() {
+				elem = v.Index(i)
+			}
+			c.walk(elem, elemType, item, elemPath)
+		}
+
+	case reflect.Map:
+		object := raw.(map[string]interface{})
+		for _, key := range sortedKeys(object) {
+			var elem reflect.Value
+			if v.IsValid() && t.Key().Kind() == reflect.String {
+				elem = v.MapIndex(reflect.ValueOf(key).Convert(t.Key()))
+			}
+			c.walk(elem, t.Elem(), object[key], appendPath(path, pathSegment{key: key, index: -1}))
+		}
+
+	case reflect.Interface:
+		// Anything goes
+	}
+}
+
+// walkStruct checks the fields of a struct against a JSON object.
+func (c *schemaChecker) walkStruct(v reflect.Value, t reflect.Type, object map[string]interface{}, path []pathSegment) {
+	fields := structFields(t)
+	constraints := constraintsOf(t)
+	for i, f := range fields {
+		fieldPath := appendPath(path, pathSegment{key: f.name, index: -1})
+		fc := constraints[i]
+
+		raw, present := lookupKey(object, f.name)
+		if !present || raw == nil {
+			if isRequired(f, fc, c.implicitRequired) {
+				issue := SchemaIssue{
+					Kind:     IssueMissingRequired,
+					Path:     pointerPath(fieldPath),
+					Expected: expectedType(f.typ, f.quoted),
+					Message:  "is required",
+				}
+				if present {
+					issue.Actual = "null"
+				}
+				c.issues = append(c.issues, issue)
+				c.violations = append(c.violations, Violation{Path: dottedPath(fieldPath), Constraint: "required", Message: "is required"})
+			}
+			continue
+		}
+
+		var fv reflect.Value
+		if v.IsValid() {
+			// Fields embedded through a nil pointer were not decoded
+			fv, _ = v.FieldByIndexErr(f.index)
+		}
+
+		if f.quoted {
+			// Quoted fields hold their value as a JSON string
+			if _, ok := raw.(string); !ok {
+				c.issues = append(c.issues, SchemaIssue{
+					Kind:     IssueTypeMismatch,
+					Path:     pointerPath(fieldPath),
+					Expected: "string",
+					Actual:   jsonType(raw),
+					Message:  fmt.Sprintf("expected string, got %s", jsonType(raw)),
+				})
+				continue
+			}
+		} else {
+			before := len(c.issues)
+			c.walk(fv, f.typ, raw, fieldPath)
+			if c.hasMismatchSince(before, fieldPath) {
+				continue
+			}
+		}
+		if fv.IsValid() {
+			c.checkRules(fv, fc.rules, fieldPath)
+		}
+	}
+
+	if !c.strict {
+		return
+	}
+	for _, key := range sortedKeys(object) {
+		if !hasField(fields, key) {
+			c.issues = append(c.issues, SchemaIssue{
+				Kind:    IssueUnknownField,
+				Path:    pointerPath(appendPath(path, pathSegment{key: key, index: -1})),
+				Actual:  jsonType(object[key]),
+				Message: "unknown field",
+			})
+		}
+	}
+}
+
+// checkRules applies the rules of a field to its decoded value.
+func (c *schemaChecker) checkRules(v reflect.Value, rules []constraintRule, path []pathSegment) {
+	for _, rule := range rules {
+		if !rule.elem {
+			c.checkRule(v, rule, path)
+			continue
+		}
+		elems := v
+		for elems.Kind() == reflect.Ptr && !elems.IsNil() {
+			elems = elems.Elem()
+		}
+		if elems.Kind() != reflect.Slice && elems.Kind() != reflect.Array {
+			continue
+		}
+		for i := 0; i < elems.Len(); i++ {
+			c.checkRule(elems.Index(i), rule, appendPath(path, pathSegment{index: i}))
+		}
+	}
+}
+
+// checkRule applies a rule to v, recording a violation if it fails.
+// Nil pointers only fail nonempty.
+func (c *schemaChecker) checkRule(v reflect.Value, rule constraintRule, path []pathSegment) {
+	var msg string
+	for v.Kind() == reflect.Ptr && msg == "" {
+		if v.IsNil() {
+			if rule.name != "nonempty" {
+				return
+			}
+			msg = "must not be empty"
+			break
+		}
+		v = v.Elem()
+	}
+	if msg == "" {
+		if msg = rule.check(v); msg == "" {
+			return
+		}
+	}
+
+	c.issues = append(c.issues, SchemaIssue{
+		Kind:       IssueConstraint,
+		Path:       pointerPath(path),
+		Constraint: rule.name,
+		Message:    msg,
+	})
+	c.violations = append(c.violations, Violation{Path: dottedPath(path), Constraint: rule.name, Message: msg})
+}
+
+// hasKind reports whether an issue of the given kind was found.
+func (c *schemaChecker) hasKind(kind IssueKind) bool {
+	for _, issue := range c.issues {
+		if issue.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMismatchSince reports whether a type mismatch at path was recorded
+// after the first n issues, in which case the decoded value is meaningless.
+func (c *schemaChecker) hasMismatchSince(n int, path []pathSegment) bool {
+	pointer := pointerPath(path)
+	for _, issue := range c.issues[n:] {
+		if issue.Kind == IssueTypeMismatch && issue.Path == pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// mismatch checks that the JSON value raw can be decoded into type t.
+// It returns the expected JSON type and false if it cannot.
+func mismatch(t reflect.Type, raw interface{}) (string, bool) {
+	t = indirect(t)
+	expected := expectedType(t, false)
+
+	switch t {
+	case rawMessageType:
+		return expected, true
+	case timeType:
+		_, ok := raw.(string)
+		return expected, ok
+	case numberType:
+		// json.Number also accepts strings holding a number
+		_, isNumber := raw.(json.Number)
+		_, isString := raw.(string)
+		return expected, isNumber || isString
+	}
+	if decodesItself(t) {
+		if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+			return expected, true
+		}
+		_, ok := raw.(string)
+		return expected, ok
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		_, ok := raw.(bool)
+		return expected, ok
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return expected, false
+		}
+		_, err := strconv.ParseInt(string(n), 10, t.Bits())
+		return expected, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return expected, false
+		}
+		_, err := strconv.ParseUint(string(n), 10, t.Bits())
+		return expected, err == nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return expected, false
+		}
+		_, err := strconv.ParseFloat(string(n), t.Bits())
+		return expected, err == nil
+	case reflect.String:
+		_, ok := raw.(string)
+		return expected, ok
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(textUnmarshalerType) {
+			_, ok := raw.(string)
+			return expected, ok
+		}
+		_, ok := raw.([]interface{})
+		return expected, ok
+	case reflect.Array:
+		_, ok := raw.([]interface{})
+		return expected, ok
+	case reflect.Map, reflect.Struct:
+		_, ok := raw.(map[string]interface{})
+		return expected, ok
+	}
+	return expected, true
+}
+
+// expectedType returns the JSON type name of values of Go type t.
+func expectedType(t reflect.Type, quoted bool) string {
+	t = indirect(t)
+	switch {
+	case quoted:
+		return "string"
+	case t == timeType:
+		return "string"
+	case t == rawMessageType:
+		return "any"
+	case t == numberType:
+		return "number"
+	case decodesItself(t):
+		if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+			return "any"
+		}
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(textUnmarshalerType) {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "any"
+}
+
+// decodesItself reports whether values of t are decoded by a custom
+// json.Unmarshaler or encoding.TextUnmarshaler.
+func decodesItself(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// jsonType returns the JSON type name of a value parsed with UseNumber.
+func jsonType(raw interface{}) string {
+	switch v := raw.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// describe formats the type of a mismatched JSON value for a message. A
+// number of the expected type is out of range for it, e.g. 300 for a uint8.
+// Messages never quote the value itself, since it comes from the model output.
+func describe(expected, actual string) string {
+	if expected == actual {
+		return actual + " out of range"
+	}
+	return actual
+}
+
+// hasField reports whether encoding/json decodes the key into one of fields.
+func hasField(fields []schemaField, key string) bool {
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupKey returns the value of the key encoding/json decodes into the
+// field name: an exact match, or else a case-insensitive one.
+func lookupKey(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for _, key := range sortedKeys(object) {
+		if strings.EqualFold(key, name) {
+			return object[key], true
+		}
+	}
+	return nil, false
+}
+
+// sortedKeys returns the keys of object in order, so issues are reported
+// deterministically.
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendPath returns a copy of path with segment appended.
+func appendPath(path []pathSegment, segment pathSegment) []pathSegment {
+	result := make([]pathSegment, len(path)+1)
+	copy(result, path)
+	result[len(path)] = segment
+	return result
+}
+
+// pointerPath formats a path as a JSON Pointer, e.g. "/invoices/2/amount".
+func pointerPath(path []pathSegment) string {
+	var b strings.Builder
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, segment := range path {
+		b.WriteByte('/')
+		if segment.index >= 0 {
+			b.WriteString(strconv.Itoa(segment.index))
+		} else {
+			b.WriteString(escaper.Replace(segment.key))
+		}
+	}
+	return b.String()
+}
+
+// dottedPath formats a path for humans, e.g. "invoices[2].amount".
+func dottedPath(path []pathSegment) string {
+	var b strings.Builder
+	for _, segment := range path {
+		if segment.index >= 0 {
+			fmt.Fprintf(&b, "[%d]", segment.index)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment.key)
+	}
+	return b.String()
+}
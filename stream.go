@@ -0,0 +1,241 @@
+package railguard
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Chunk is a piece of streamed LLM output.
+type Chunk struct {
+	// Text is the next piece of generated text.
+	Text string
+
+	// Err reports a generation failure. A chunk with a non-nil Err ends the stream.
+	Err error
+}
+
+// StreamingClient represents an LLM that can stream its output as it is generated.
+// A Client that also implements StreamingClient is streamed by Guard.Stream.
+type StreamingClient interface {
+	// GenerateStream starts generating a response for the given prompt.
+	// The returned channel yields chunks in order and must be closed when
+	// generation ends. Implementations must stop sending and close the channel
+	// when the context is canceled.
+	GenerateStream(ctx context.Context, prompt string) (<-chan Chunk, error)
+}
+
+// StreamingClientFunc is an adapter that allows ordinary functions to be used as StreamingClients.
+type StreamingClientFunc func(ctx context.Context, prompt string) (<-chan Chunk, error)
+
+// GenerateStream implements the StreamingClient interface by calling the function itself.
+func (f StreamingClientFunc) GenerateStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return f(ctx, prompt)
+}
+
+// StreamValidator is a Validator that can also reject output while it is
+// still being streamed. Guard.Stream calls ValidateStream with the output
+// accumulated so far before each chunk is delivered, and cancels generation
+// as soon as it returns an error. Validate still runs on the assembled output.
+type StreamValidator interface {
+	Validator
+
+	// ValidateStream examines the partial output and returns an error if it
+	// can already be rejected. A nil return means the output may still pass.
+	ValidateStream(ctx context.Context, partial string) error
+}
+
+// Stream executes the Guard pipeline for the given prompt, delivering output
+// chunks to onChunk as they are generated. The pipeline consists of:
+//  1. Apply timeout (if configured)
+//  2. Run detectors (fail fast, no retry)
+//  3. Stream: each chunk is checked by StreamValidators before delivery
+//  4. Transform → validate → parse schema on the assembled output
+//
+// If the client does not implement StreamingClient, the complete output is
+// generated like in Run, through the chat client if one was set with
+// WithChatClient, and delivered as a single chunk. Stream makes a single
+// generation attempt, since chunks that were already delivered cannot be
+// taken back. WithAttemptTimeout limits that attempt, including the time
+// spent in onChunk.
+//
+// Stream takes a single prompt, sent as one user message. There is no
+// streaming counterpart of RunMessages, since StreamingClient only accepts a
+// prompt; use RunMessages for conversations with a system prompt or earlier
+// turns.
+//
+// If onChunk returns an error, generation is canceled and that error is returned.
+func (g *Guard) Stream(ctx context.Context, prompt string, onChunk func(chunk string) error) (*Result, error) {
+	startTime := time.Now()
+
+	// Apply timeout if configured
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	ctx, state := withRunState(ctx)
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	g.observer.OnRunStart(ctx, RunStartEvent{Messages: messages, Start: startTime})
+	result, attempts, err := g.runStream(ctx, state, prompt, onChunk, startTime)
+	g.observer.OnRunEnd(ctx, RunEndEvent{
+		Attempts: attempts,
+		Duration: time.Since(startTime),
+		Result:   result,
+		Err:      err,
+	})
+
+	return result, err
+}
+
+// runStream executes detection and the single streaming attempt for Stream.
+// It returns the number of attempts made alongside the result.
+func (g *Guard) runStream(ctx context.Context, state *runState, prompt string, onChunk func(chunk string) error, startTime time.Time) (*Result, int, error) {
+	// Phase 1: Detection (fail fast, no retry)
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	if err := g.runDetectors(ctx, detectionInput(messages, g.detectRoles)); err != nil {
+		return nil, 0, err
+	}
+
+	// Phase 2: Streaming generation with incremental validation
+	g.observer.OnGenerationStart(ctx, GenerationStartEvent{Attempt: 1, Messages: messages})
+	start := time.Now()
+	output, err := g.stream(ctx, prompt, onChunk)
+	latency := time.Since(start)
+	g.observer.OnGenerationEnd(ctx, GenerationEndEvent{
+		Attempt:  1,
+		Duration: latency,
+		Output:   output,
+		Err:      err,
+	})
+	if err != nil {
+		return nil, 1, err
+	}
+
+	// Phase 3: Transformation, validation, and schema on the assembled output
+	transformed, parsed, fixes, err := g.process(ctx, 1, output)
+	if err != nil {
+		return nil, 1, err
+	}
+
+	return &Result{
+		Raw:    output,
+		Output: transformed,
+		Parsed: parsed,
+		Metadata: Metadata{
+			Attempts: 1,
+			Duration: time.Since(startTime),
+			Trace: []AttemptRecord{{
+				Attempt:           1,
+				Raw:               output,
+				GenerationLatency: latency,
+				Client:            state.lastClient(),
+				StopReason:        state.lastStopReason(),
+				JSONFixes:         fixes,
+			}},
+			Client:         state.lastClient(),
+			ClientAttempts: state.attempts(),
+			StopReason:     state.lastStopReason(),
+			JSONRepaired:   len(fixes) > 0,
+			JSONFixes:      fixes,
+		},
+	}, 1, nil
+}
+
+// stream generates the output for the prompt, running stream validators on
+// the accumulated output before delivering each chunk. Returns the assembled output.
+func (g *Guard) stream(ctx context.Context, prompt string, onChunk func(chunk string) error) (string, error) {
+	streamer, ok := g.streamingClient()
+	if !ok {
+		output, err := g.generate(ctx, []Message{{Role: RoleUser, Content: prompt}})
+		if err != nil {
+			return "", &GenerationError{Err: err}
+		}
+		if err := g.runStreamValidators(ctx, output); err != nil {
+			return "", err
+		}
+		if err := onChunk(output); err != nil {
+			return "", err
+		}
+		return output, nil
+	}
+
+	// Cancel upstream generation as soon as the stream is rejected
+	var streamCtx context.Context
+	var cancel context.CancelFunc
+	if g.attemptTimeout > 0 {
+		streamCtx, cancel = context.WithTimeout(ctx, g.attemptTimeout)
+	} else {
+		streamCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	chunks, err := streamer.GenerateStream(streamCtx, prompt)
+	if err != nil {
+		return "", &GenerationError{Err: g.attemptError(ctx, streamCtx, err)}
+	}
+	// Drain any chunks sent after an early return so the producer is not blocked
+	defer func() {
+		cancel()
+		go func() {
+			for range chunks {
+				// Discard remaining chunks
+			}
+		}()
+	}()
+
+	var sb strings.Builder
+	for {
+		select {
+		case <-streamCtx.Done():
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", &GenerationError{Err: g.attemptError(ctx, streamCtx, streamCtx.Err())}
+		case chunk, ok := <-chunks:
+			if !ok {
+				return sb.String(), nil
+			}
+			if chunk.Err != nil {
+				return "", &GenerationError{Err: g.attemptError(ctx, streamCtx, chunk.Err)}
+			}
+
+			sb.WriteString(chunk.Text)
+			if err := g.runStreamValidators(ctx, sb.String()); err != nil {
+				return "", err
+			}
+			if err := onChunk(chunk.Text); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// streamingClient returns the client used by Stream, if it can stream: the
+// client set with WithClient, or the chat client set with WithChatClient.
+func (g *Guard) streamingClient() (StreamingClient, bool) {
+	if streamer, ok := g.client.(StreamingClient); ok {
+		return streamer, true
+	}
+	streamer, ok := g.chat.(StreamingClient)
+	return streamer, ok
+}
+
+// runStreamValidators runs all validators that implement StreamValidator
+// against the partial output. Returns a ValidationError on the first failure.
+func (g *Guard) runStreamValidators(ctx context.Context, partial string) error {
+	for _, validator := range g.validators {
+		sv, ok := validator.(StreamValidator)
+		if !ok {
+			continue
+		}
+		if err := sv.ValidateStream(ctx, partial); err != nil {
+			return &ValidationError{
+				Validator: validator.Name(),
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
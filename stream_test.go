@@ -0,0 +1,272 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RasmusHilmar1/railguard"
+	"github.com/RasmusHilmar1/railguard/validators"
+)
+
+// streamingClient streams a fixed list of chunks and records cancellation.
+type streamingClient struct {
+	chunks   []railguard.Chunk
+	canceled chan struct{}
+}
+
+func newStreamingClient(texts ...string) *streamingClient {
+	c := &streamingClient{canceled: make(chan struct{})}
+	for _, text := range texts {
+		c.chunks = append(c.chunks, railguard.Chunk{Text: text})
+	}
+	return c
+}
+
+func (c *streamingClient) Generate(ctx context.Context, prompt string) (string, error) {
+	var sb strings.Builder
+	for _, chunk := range c.chunks {
+		sb.WriteString(chunk.Text)
+	}
+	return sb.String(), nil
+}
+
+func (c *streamingClient) GenerateStream(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+	ch := make(chan railguard.Chunk)
+	go func() {
+		defer close(ch)
+		for _, chunk := range c.chunks {
+			select {
+			case <-ctx.Done():
+				close(c.canceled)
+				return
+			case ch <- chunk:
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func TestGuardStream(t *testing.T) {
+	t.Run("delivers chunks and parses assembled output", func(t *testing.T) {
+		type Response struct {
+			Message string `json:"message"`
+		}
+
+		client := newStreamingClient(`{"mess`, `age": "hi`, `"}`)
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		var received []string
+		result, err := g.Stream(context.Background(), "test", func(chunk string) error {
+			received = append(received, chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(received) != 3 {
+			t.Errorf("expected 3 chunks, got %d", len(received))
+		}
+		if result.Raw != `{"message": "hi"}` {
+			t.Errorf("unexpected raw output: %q", result.Raw)
+		}
+		if resp := result.Parsed.(*Response); resp.Message != "hi" {
+			t.Errorf("expected message 'hi', got %q", resp.Message)
+		}
+	})
+
+	t.Run("stream validator cancels generation mid-stream", func(t *testing.T) {
+		client := newStreamingClient("1234", "5678", "9012", "3456")
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithValidators(validators.NewMaxLength(6)),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		var received []string
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error {
+			received = append(received, chunk)
+			return nil
+		})
+
+		var validationErr *railguard.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+		if validationErr.Validator != "maxlength" {
+			t.Errorf("unexpected validator: %s", validationErr.Validator)
+		}
+		if len(received) != 1 {
+			t.Errorf("expected only the first chunk to be delivered, got %q", received)
+		}
+
+		select {
+		case <-client.canceled:
+		case <-time.After(time.Second):
+			t.Error("expected upstream generation to be canceled")
+		}
+	})
+
+	t.Run("non-stream validators run on assembled output", func(t *testing.T) {
+		client := newStreamingClient("not ", "json")
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithValidators(validators.NewJSON()),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error { return nil })
+		var validationErr *railguard.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("chunk error surfaces GenerationError", func(t *testing.T) {
+		client := railguard.StreamingClientFunc(func(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+			ch := make(chan railguard.Chunk, 2)
+			ch <- railguard.Chunk{Text: "partial"}
+			ch <- railguard.Chunk{Err: errors.New("connection reset")}
+			close(ch)
+			return ch, nil
+		})
+
+		g, err := railguard.New(railguard.WithClient(&streamOnlyClient{client}))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error { return nil })
+		var genErr *railguard.GenerationError
+		if !errors.As(err, &genErr) {
+			t.Fatalf("expected GenerationError, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("onChunk error aborts the stream", func(t *testing.T) {
+		client := newStreamingClient("a", "b", "c")
+		g, err := railguard.New(railguard.WithClient(client))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		stopErr := errors.New("client went away")
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error {
+			return stopErr
+		})
+		if !errors.Is(err, stopErr) {
+			t.Errorf("expected onChunk error, got %v", err)
+		}
+	})
+
+	t.Run("non-streaming client delivers a single chunk", func(t *testing.T) {
+		g, err := railguard.New(railguard.WithClient(&mockClient{}))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		var received []string
+		result, err := g.Stream(context.Background(), "test", func(chunk string) error {
+			received = append(received, chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(received) != 1 || received[0] != "response" {
+			t.Errorf("expected a single 'response' chunk, got %q", received)
+		}
+		if result.Raw != "response" {
+			t.Errorf("unexpected raw output: %q", result.Raw)
+		}
+	})
+
+	t.Run("detector blocks prompt before streaming", func(t *testing.T) {
+		client := newStreamingClient("should not stream")
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithDetectors(railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+				return errors.New("blocked")
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error {
+			t.Error("no chunks should be delivered")
+			return nil
+		})
+		var detectionErr *railguard.DetectionError
+		if !errors.As(err, &detectionErr) {
+			t.Errorf("expected DetectionError, got %v", err)
+		}
+	})
+
+	t.Run("uses the chat client", func(t *testing.T) {
+		var got []railguard.Message
+		chat := railguard.ChatClientFunc(func(ctx context.Context, messages []railguard.Message) (string, error) {
+			got = messages
+			return "ok", nil
+		})
+		g, err := railguard.New(railguard.WithChatClient(chat))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := g.Stream(context.Background(), "test", func(chunk string) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Output != "ok" || len(got) != 1 || got[0].Role != railguard.RoleUser || got[0].Content != "test" {
+			t.Errorf("expected chat call with the prompt, got %+v (output %q)", got, result.Output)
+		}
+	})
+
+	t.Run("applies the attempt timeout", func(t *testing.T) {
+		hanging := railguard.StreamingClientFunc(func(ctx context.Context, prompt string) (<-chan railguard.Chunk, error) {
+			ch := make(chan railguard.Chunk)
+			go func() {
+				defer close(ch)
+				ch <- railguard.Chunk{Text: "partial"}
+				<-ctx.Done()
+			}()
+			return ch, nil
+		})
+		g, err := railguard.New(
+			railguard.WithClient(&streamOnlyClient{StreamingClient: hanging}),
+			railguard.WithAttemptTimeout(20*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = g.Stream(context.Background(), "test", func(chunk string) error { return nil })
+		var genErr *railguard.GenerationError
+		if !errors.As(err, &genErr) || !errors.Is(err, railguard.ErrAttemptTimeout) {
+			t.Errorf("expected GenerationError wrapping ErrAttemptTimeout, got %v", err)
+		}
+	})
+}
+
+// streamOnlyClient adds a Generate method to a StreamingClient.
+type streamOnlyClient struct {
+	railguard.StreamingClient
+}
+
+func (c *streamOnlyClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return "", errors.New("not supported")
+}
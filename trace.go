@@ -0,0 +1,94 @@
+package railguard
+
+import (
+	"time"
+)
+
+// Stage identifies the pipeline stage that rejected an attempt.
+type Stage string
+
+const (
+	// StageGeneration means the client failed to generate an output.
+	StageGeneration Stage = "generation"
+
+	// StageTransform means a transformer rejected the output.
+	StageTransform Stage = "transform"
+
+	// StageValidation means a validator rejected the output.
+	StageValidation Stage = "validation"
+
+	// StageSchema means the output did not match the schema.
+	StageSchema Stage = "schema"
+)
+
+// AttemptRecord describes a single generation attempt of a run.
+// Records are available in Metadata.Trace on success and in
+// MaxRetriesError.Trace when every attempt failed.
+type AttemptRecord struct {
+	// Attempt is the attempt number (1 = first attempt).
+	Attempt int
+
+	// Raw is the raw output of the attempt, or empty if generation failed.
+	Raw string
+
+	// Err is the error that rejected the attempt, or nil if it succeeded.
+	Err error
+
+	// Stage is the pipeline stage that rejected the attempt.
+	// It is empty if the attempt succeeded.
+	Stage Stage
+
+	// GenerationLatency is the time the client took to generate the output.
+	GenerationLatency time.Duration
+
+	// Backoff is the time slept before the attempt. It is zero for the first attempt.
+	Backoff time.Duration
+
+	// Client is the name of the client that produced the output when using a
+	// FallbackClient. It is empty if generation failed or the client has no name.
+	Client string
+
+	// StopReason is the reason the provider stopped generating, as reported
+	// by the client with SetStopReason. It is empty if the client reports none.
+	StopReason string
+
+	// Hedged is true if a hedged request was sent during the attempt.
+	Hedged bool
+
+	// JSONFixes lists the fixes applied to the output by a lenient schema.
+	// It is empty if the output was not repaired; see WithLenientSchema.
+	JSONFixes []JSONFix
+}
+
+// stageOf returns the pipeline stage that produced err.
+func stageOf(err error) Stage {
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case *TransformError:
+		return StageTransform
+	case *ValidationError:
+		return StageValidation
+	case *SchemaError:
+		return StageSchema
+	case *MultiError:
+		// Collect-all mode only aggregates validator failures after generation
+		if len(e.Errors) > 0 {
+			return stageOf(e.Errors[0])
+		}
+		return StageValidation
+	default:
+		return StageGeneration
+	}
+}
+
+// countHedges returns the number of attempts in trace that sent a hedge.
+func countHedges(trace []AttemptRecord) int {
+	n := 0
+	for _, record := range trace {
+		if record.Hedged {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,51 @@
+package railguard
+
+import "context"
+
+// Transformer rewrites LLM outputs after generation.
+// Transformers run between generation and validation, so validators and the
+// schema see the transformed output rather than the raw response. They are
+// used for cleanup like stripping markdown code fences or extracting the
+// contents of an XML tag.
+//
+// Transformation failures may be retried depending on the Guard's configuration,
+// as LLM outputs are non-deterministic.
+type Transformer interface {
+	// Transform returns the rewritten output, or an error if the output
+	// cannot be transformed.
+	Transform(ctx context.Context, output string) (string, error)
+
+	// Name returns a human-readable identifier for this transformer.
+	// Used in error messages and logging.
+	Name() string
+}
+
+// TransformerFunc is an adapter that allows ordinary functions to be used as Transformers.
+// The Name() method returns "custom" for function-based transformers.
+type TransformerFunc func(ctx context.Context, output string) (string, error)
+
+// Transform implements the Transformer interface by calling the function itself.
+func (f TransformerFunc) Transform(ctx context.Context, output string) (string, error) {
+	return f(ctx, output)
+}
+
+// Name returns "custom" for function-based transformers.
+func (f TransformerFunc) Name() string {
+	return "custom"
+}
+
+// Transformers is a convenience type for working with multiple transformers.
+type Transformers []Transformer
+
+// Transform runs all transformers in sequence, feeding each one the output of
+// the previous. It returns the first error encountered.
+func (t Transformers) Transform(ctx context.Context, output string) (string, error) {
+	for _, transformer := range t {
+		var err error
+		output, err = transformer.Transform(ctx, output)
+		if err != nil {
+			return "", err
+		}
+	}
+	return output, nil
+}
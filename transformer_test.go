@@ -0,0 +1,84 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+func TestTransformerFunc(t *testing.T) {
+	t.Run("rewrites output", func(t *testing.T) {
+		transformer := railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+			return strings.ToUpper(output), nil
+		})
+
+		got, err := transformer.Transform(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "TEST" {
+			t.Errorf("expected 'TEST', got %q", got)
+		}
+	})
+
+	t.Run("has correct name", func(t *testing.T) {
+		transformer := railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+			return output, nil
+		})
+
+		if name := transformer.Name(); name != "custom" {
+			t.Errorf("expected name 'custom', got %q", name)
+		}
+	})
+}
+
+func TestTransformers(t *testing.T) {
+	t.Run("empty transformers pass through", func(t *testing.T) {
+		got, err := railguard.Transformers{}.Transform(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "test" {
+			t.Errorf("expected 'test', got %q", got)
+		}
+	})
+
+	t.Run("chains in order", func(t *testing.T) {
+		transformers := railguard.Transformers{
+			railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+				return output + "a", nil
+			}),
+			railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+				return output + "b", nil
+			}),
+		}
+
+		got, err := transformers.Transform(context.Background(), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "ab" {
+			t.Errorf("expected 'ab', got %q", got)
+		}
+	})
+
+	t.Run("returns first error", func(t *testing.T) {
+		expectedErr := errors.New("first error")
+		transformers := railguard.Transformers{
+			railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+				return "", expectedErr
+			}),
+			railguard.TransformerFunc(func(ctx context.Context, output string) (string, error) {
+				t.Error("second transformer should not run")
+				return output, nil
+			}),
+		}
+
+		if _, err := transformers.Transform(context.Background(), "test"); err != expectedErr {
+			t.Errorf("expected error %v, got %v", expectedErr, err)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+// Package transformers provides built-in transformer implementations for railguard.
+package transformers
+
+import (
+	"context"
+
+	"github.com/RasmusHilmar1/railguard/validators"
+)
+
+// CodeFence strips markdown code fences from the output.
+// This is useful when LLMs wrap JSON in ```json ... ``` blocks, which would
+// otherwise fail schema parsing. Output without a code fence is only trimmed.
+type CodeFence struct {
+	extractor *validators.JSONExtractor
+}
+
+// NewCodeFence creates a new CodeFence transformer.
+func NewCodeFence() *CodeFence {
+	return &CodeFence{extractor: validators.NewJSONExtractor()}
+}
+
+// Transform returns the contents of the code fence wrapping the output.
+func (c *CodeFence) Transform(ctx context.Context, output string) (string, error) {
+	// Check context first
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	return c.extractor.Extract(output), nil
+}
+
+// Name returns the transformer's name.
+func (c *CodeFence) Name() string {
+	return "codefence"
+}
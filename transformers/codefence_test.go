@@ -0,0 +1,60 @@
+package transformers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard/transformers"
+)
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "json code block",
+			input:  "```json\n{\"key\": \"value\"}\n```",
+			expect: `{"key": "value"}`,
+		},
+		{
+			name:   "generic code block",
+			input:  "```\n[1, 2, 3]\n```",
+			expect: `[1, 2, 3]`,
+		},
+		{
+			name:   "bare output",
+			input:  "  {\"key\": \"value\"}  ",
+			expect: `{"key": "value"}`,
+		},
+	}
+
+	tr := transformers.NewCodeFence()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.Transform(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+
+	t.Run("name", func(t *testing.T) {
+		if tr.Name() != "codefence" {
+			t.Errorf("unexpected name: %s", tr.Name())
+		}
+	})
+
+	t.Run("respects context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := tr.Transform(ctx, "test"); err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
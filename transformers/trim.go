@@ -0,0 +1,39 @@
+package transformers
+
+import (
+	"context"
+	"strings"
+)
+
+// byteOrderMark is the UTF-8 encoded byte order mark some models and
+// proxies prepend to their output.
+const byteOrderMark = "\ufeff"
+
+// Trim removes a leading byte order mark and surrounding whitespace from the output.
+type Trim struct{}
+
+// NewTrim creates a new Trim transformer.
+func NewTrim() *Trim {
+	return &Trim{}
+}
+
+// Transform returns the output without a byte order mark or surrounding whitespace.
+func (t *Trim) Transform(ctx context.Context, output string) (string, error) {
+	// Check context first
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	trimmed := strings.TrimSpace(output)
+	for strings.HasPrefix(trimmed, byteOrderMark) {
+		trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, byteOrderMark))
+	}
+	return trimmed, nil
+}
+
+// Name returns the transformer's name.
+func (t *Trim) Name() string {
+	return "trim"
+}
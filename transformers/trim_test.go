@@ -0,0 +1,40 @@
+package transformers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard/transformers"
+)
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{name: "whitespace", input: "\n\t {\"a\": 1} \r\n", expect: `{"a": 1}`},
+		{name: "byte order mark", input: "\ufeff{\"a\": 1}", expect: `{"a": 1}`},
+		{name: "whitespace around byte order mark", input: " \ufeff\n{\"a\": 1}\n", expect: `{"a": 1}`},
+		{name: "unchanged", input: "hello", expect: "hello"},
+	}
+
+	tr := transformers.NewTrim()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.Transform(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+
+	t.Run("name", func(t *testing.T) {
+		if tr.Name() != "trim" {
+			t.Errorf("unexpected name: %s", tr.Name())
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// XMLTag extracts the contents of an XML-style tag from the output.
+// This is useful when the prompt asks the LLM to wrap its answer in a tag
+// such as <answer>...</answer> so that surrounding reasoning can be discarded.
+type XMLTag struct {
+	tag      string
+	pattern  *regexp.Regexp
+	optional bool
+}
+
+// NewXMLTag creates a new XMLTag transformer that extracts the contents of
+// the first <tag>...</tag> element. Attributes on the opening tag are allowed.
+// By default, output without the tag is rejected.
+func NewXMLTag(tag string) *XMLTag {
+	quoted := regexp.QuoteMeta(tag)
+	return &XMLTag{
+		tag:     tag,
+		pattern: regexp.MustCompile(`(?s)<` + quoted + `(?:\s[^>]*)?>(.*?)</` + quoted + `\s*>`),
+	}
+}
+
+// WithOptional configures the transformer to pass output through unchanged
+// when the tag is missing, instead of returning an error.
+func (x *XMLTag) WithOptional() *XMLTag {
+	return &XMLTag{
+		tag:      x.tag,
+		pattern:  x.pattern,
+		optional: true,
+	}
+}
+
+// Transform returns the trimmed contents of the first matching tag.
+func (x *XMLTag) Transform(ctx context.Context, output string) (string, error) {
+	// Check context first
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	match := x.pattern.FindStringSubmatch(output)
+	if match == nil {
+		if x.optional {
+			return output, nil
+		}
+		return "", fmt.Errorf("missing <%s> tag in output", x.tag)
+	}
+
+	return strings.TrimSpace(match[1]), nil
+}
+
+// Name returns the transformer's name.
+func (x *XMLTag) Name() string {
+	return "xmltag:" + x.tag
+}
+
+// Tag returns the configured tag name.
+func (x *XMLTag) Tag() string {
+	return x.tag
+}
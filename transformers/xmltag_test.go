@@ -0,0 +1,78 @@
+package transformers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard/transformers"
+)
+
+func TestXMLTag(t *testing.T) {
+	t.Run("extracts tag contents", func(t *testing.T) {
+		tr := transformers.NewXMLTag("answer")
+		got, err := tr.Transform(context.Background(), "Let me think.\n<answer>\n{\"ok\": true}\n</answer>\nDone.")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != `{"ok": true}` {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("allows attributes", func(t *testing.T) {
+		tr := transformers.NewXMLTag("answer")
+		got, err := tr.Transform(context.Background(), `<answer format="json">42</answer>`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "42" {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("uses first match", func(t *testing.T) {
+		tr := transformers.NewXMLTag("a")
+		got, err := tr.Transform(context.Background(), "<a>one</a><a>two</a>")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "one" {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("does not match tags with a shared prefix", func(t *testing.T) {
+		tr := transformers.NewXMLTag("a")
+		if _, err := tr.Transform(context.Background(), "<answer>x</answer>"); err == nil {
+			t.Error("expected error for missing tag")
+		}
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		tr := transformers.NewXMLTag("answer")
+		if _, err := tr.Transform(context.Background(), "no tags here"); err == nil {
+			t.Error("expected error for missing tag")
+		}
+	})
+
+	t.Run("optional passes through", func(t *testing.T) {
+		tr := transformers.NewXMLTag("answer").WithOptional()
+		got, err := tr.Transform(context.Background(), "no tags here")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "no tags here" {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("name includes tag", func(t *testing.T) {
+		tr := transformers.NewXMLTag("answer")
+		if tr.Name() != "xmltag:answer" {
+			t.Errorf("unexpected name: %s", tr.Name())
+		}
+		if tr.Tag() != "answer" {
+			t.Errorf("unexpected tag: %s", tr.Tag())
+		}
+	})
+}
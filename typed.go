@@ -0,0 +1,107 @@
+package railguard
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// TypedResult contains the output from a successful TypedGuard run.
+// It mirrors Result, with Parsed typed as *T.
+type TypedResult[T any] struct {
+	// Raw is the raw string output from the LLM.
+	Raw string
+
+	// Output is the output after all transformers have run.
+	Output string
+
+	// Parsed is the structured output parsed into T.
+	Parsed *T
+
+	// Metadata contains information about the execution.
+	Metadata Metadata
+}
+
+// TypedGuard runs a Guard and returns its parsed output as *T,
+// removing the need for type assertions on Result.Parsed.
+type TypedGuard[T any] struct {
+	guard *Guard
+}
+
+// NewTyped creates a TypedGuard for T from an existing Guard.
+// If the guard has no schema, one is derived from T using the guard's strict
+// mode setting. If the guard already has a schema, its target type must be T,
+// otherwise ErrSchemaTypeMismatch is returned. T must be a struct type.
+//
+// Example:
+//
+//	typed, err := railguard.NewTyped[Response](guard)
+//	result, err := typed.Run(ctx, prompt)
+//	fmt.Println(result.Parsed.Message)
+func NewTyped[T any](g *Guard) (*TypedGuard[T], error) {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+
+	if g.schema != nil {
+		if g.schema.TargetType() != target {
+			return nil, fmt.Errorf("%w: guard schema is %v, want %v",
+				ErrSchemaTypeMismatch, g.schema.TargetType(), target)
+		}
+		return &TypedGuard[T]{guard: g}, nil
+	}
+
+	schema, err := NewSchema(new(T))
+	if err != nil {
+		return nil, err
+	}
+	g.configureSchema(schema)
+
+	// Copy the guard so the derived schema does not affect untyped runs
+	typed := *g
+	typed.schema = schema
+	return &TypedGuard[T]{guard: &typed}, nil
+}
+
+// Run executes the Guard pipeline for the given prompt and returns the parsed output as *T.
+func (t *TypedGuard[T]) Run(ctx context.Context, prompt string) (*TypedResult[T], error) {
+	return t.RunMessages(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+// RunMessages executes the Guard pipeline for the given conversation and
+// returns the parsed output as *T.
+func (t *TypedGuard[T]) RunMessages(ctx context.Context, messages []Message) (*TypedResult[T], error) {
+	result, err := t.guard.RunMessages(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	return newTypedResult[T](result), nil
+}
+
+// Guard returns the underlying Guard, including any schema derived from T.
+func (t *TypedGuard[T]) Guard() *Guard {
+	return t.guard
+}
+
+// RunAs executes the Guard pipeline for the given prompt and returns the
+// parsed output as *T. It is a shorthand for NewTyped followed by Run.
+//
+// Example:
+//
+//	result, err := railguard.RunAs[Response](ctx, guard, prompt)
+func RunAs[T any](ctx context.Context, g *Guard, prompt string) (*TypedResult[T], error) {
+	typed, err := NewTyped[T](g)
+	if err != nil {
+		return nil, err
+	}
+	return typed.Run(ctx, prompt)
+}
+
+// newTypedResult converts a Result whose Parsed field holds a *T.
+func newTypedResult[T any](result *Result) *TypedResult[T] {
+	parsed, _ := result.Parsed.(*T)
+	return &TypedResult[T]{
+		Raw:      result.Raw,
+		Output:   result.Output,
+		Parsed:   parsed,
+		Metadata: result.Metadata,
+	}
+}
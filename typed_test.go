@@ -0,0 +1,127 @@
+package railguard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RasmusHilmar1/railguard"
+)
+
+func TestTypedGuard(t *testing.T) {
+	type Response struct {
+		Message string `json:"message"`
+	}
+	type Other struct {
+		Value int `json:"value"`
+	}
+
+	client := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+		return `{"message": "hello"}`, nil
+	})
+
+	t.Run("derives schema from type parameter", func(t *testing.T) {
+		g, err := railguard.New(railguard.WithClient(client))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		typed, err := railguard.NewTyped[Response](g)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result, err := typed.Run(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Parsed.Message != "hello" {
+			t.Errorf("expected message 'hello', got %q", result.Parsed.Message)
+		}
+		if g.Schema() != nil {
+			t.Error("deriving a schema should not modify the original guard")
+		}
+	})
+
+	t.Run("uses matching guard schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(&Response{}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		result, err := railguard.RunAs[Response](context.Background(), g, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Parsed.Message != "hello" {
+			t.Errorf("expected message 'hello', got %q", result.Parsed.Message)
+		}
+		if result.Metadata.Attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", result.Metadata.Attempts)
+		}
+	})
+
+	t.Run("rejects mismatched schema", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithSchema(&Other{}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := railguard.NewTyped[Response](g); !errors.Is(err, railguard.ErrSchemaTypeMismatch) {
+			t.Errorf("expected ErrSchemaTypeMismatch, got %v", err)
+		}
+	})
+
+	t.Run("rejects non-struct type", func(t *testing.T) {
+		g, err := railguard.New(railguard.WithClient(client))
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := railguard.NewTyped[string](g); !errors.Is(err, railguard.ErrInvalidSchema) {
+			t.Errorf("expected ErrInvalidSchema, got %v", err)
+		}
+	})
+
+	t.Run("derived schema follows strict mode setting", func(t *testing.T) {
+		extra := railguard.ClientFunc(func(ctx context.Context, prompt string) (string, error) {
+			return `{"message": "hello", "extra": true}`, nil
+		})
+
+		g, err := railguard.New(
+			railguard.WithClient(extra),
+			railguard.WithStrictSchema(false),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		if _, err := railguard.RunAs[Response](context.Background(), g, "test"); err != nil {
+			t.Errorf("unexpected error in non-strict mode: %v", err)
+		}
+	})
+
+	t.Run("propagates pipeline errors", func(t *testing.T) {
+		g, err := railguard.New(
+			railguard.WithClient(client),
+			railguard.WithDetectors(railguard.DetectorFunc(func(ctx context.Context, prompt string) error {
+				return errors.New("blocked")
+			})),
+		)
+		if err != nil {
+			t.Fatalf("failed to create guard: %v", err)
+		}
+
+		_, err = railguard.RunAs[Response](context.Background(), g, "test")
+		var detectionErr *railguard.DetectionError
+		if !errors.As(err, &detectionErr) {
+			t.Errorf("expected DetectionError, got %v", err)
+		}
+	})
+}
@@ -57,6 +57,13 @@ func (m *MaxLength) Validate(ctx context.Context, output string) error {
 	return nil
 }
 
+// ValidateStream checks if the partial output is within the limit.
+// Output only grows while streaming, so a partial output over the limit
+// can be rejected before generation finishes.
+func (m *MaxLength) ValidateStream(ctx context.Context, partial string) error {
+	return m.Validate(ctx, partial)
+}
+
 // Name returns the validator's name.
 func (m *MaxLength) Name() string {
 	return "maxlength"
@@ -182,6 +189,13 @@ func (r *LengthRange) Validate(ctx context.Context, output string) error {
 	return nil
 }
 
+// ValidateStream checks if the partial output is within the maximum length.
+// The minimum is only checked on the assembled output.
+func (r *LengthRange) ValidateStream(ctx context.Context, partial string) error {
+	limit := &MaxLength{limit: r.max, runes: r.runes}
+	return limit.Validate(ctx, partial)
+}
+
 // Name returns the validator's name.
 func (r *LengthRange) Name() string {
 	return "lengthrange"
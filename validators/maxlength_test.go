@@ -105,6 +105,16 @@ func TestMaxLength(t *testing.T) {
 			t.Errorf("expected context.Canceled, got %v", err)
 		}
 	})
+
+	t.Run("ValidateStream rejects partial output over limit", func(t *testing.T) {
+		v := validators.NewMaxLength(5)
+		if err := v.ValidateStream(context.Background(), "123"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := v.ValidateStream(context.Background(), "123456"); err == nil {
+			t.Error("expected error for partial output exceeding limit")
+		}
+	})
 }
 
 func TestMinLength(t *testing.T) {
@@ -181,6 +191,16 @@ func TestLengthRange(t *testing.T) {
 		}
 	})
 
+	t.Run("ValidateStream ignores minimum", func(t *testing.T) {
+		v := validators.NewLengthRange(5, 10)
+		if err := v.ValidateStream(context.Background(), "12"); err != nil {
+			t.Errorf("unexpected error for short partial output: %v", err)
+		}
+		if err := v.ValidateStream(context.Background(), "12345678901"); err == nil {
+			t.Error("expected error for partial output exceeding maximum")
+		}
+	})
+
 	t.Run("Name returns correct value", func(t *testing.T) {
 		v := validators.NewLengthRange(5, 10)
 		if v.Name() != "lengthrange" {